@@ -0,0 +1,387 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// androidResourceQualifiers maps DeepL target languages to Android resource
+// qualifiers where the generic rule does not apply.
+var androidResourceQualifiers = map[string]string{
+	"ZH":      "zh-rCN",
+	"ZH-HANS": "zh-rCN",
+	"ZH-HANT": "zh-rTW",
+	"ES-419":  "b+es+419",
+	"HE":      "iw", // legacy codes still expected by older Android versions
+	"ID":      "in",
+}
+
+// Escaped backslashes and unicode escapes such as \u2026 are left as is,
+// as they are meaningless to the translation engine.
+var (
+	androidUnescaper = strings.NewReplacer(
+		`\\`, `\\`, `\'`, `'`, `\"`, `"`, `\n`, "\n", `\t`, "\t", `\@`, `@`, `\?`, `?`,
+	)
+	androidEscaper = strings.NewReplacer(
+		`'`, `\'`, `"`, `\"`, "\n", `\n`, "\t", `\t`,
+	)
+)
+
+// AndroidValuesDir returns the name of the Android resource directory for a
+// DeepL target language, e.g. "values-de", "values-pt-rBR" or "values-zh-rCN".
+func AndroidValuesDir(targetLang string) string {
+	lang := strings.ToUpper(targetLang)
+	if qualifier, ok := androidResourceQualifiers[lang]; ok {
+		return "values-" + qualifier
+	}
+	base, region, ok := strings.Cut(lang, "-")
+	if !ok {
+		return "values-" + strings.ToLower(base)
+	}
+	return "values-" + strings.ToLower(base) + "-r" + region
+}
+
+// AndroidStrings is a parsed Android strings.xml resource file.
+type AndroidStrings struct {
+	Strings []*AndroidString
+
+	data    []byte
+	removed [][2]int64 // resources left out of translated files
+	plurals []androidPluralEdit
+}
+
+// androidPluralEdit replaces the items of a <plurals> resource, whose
+// plural categories differ from those of the target language.
+type androidPluralEdit struct {
+	start, end int64 // offsets from the first item to the end of the last
+	items      []*AndroidString
+	sep        string // text between items
+}
+
+// AndroidString is a translatable text of a <string>, or an <item> of a
+// <plurals> or <string-array> resource. Value holds the raw XML content,
+// including Android escapes and any markup.
+type AndroidString struct {
+	Name     string
+	Kind     string // "string", "plurals" or "string-array"
+	Quantity string // plural quantity, e.g. "one" or "other"
+	Index    int    // index within a string array
+	Value    string
+
+	original   string
+	start, end int64 // offsets of the raw content
+
+	elemStart, elemEnd int64 // offsets of the whole <item> element
+}
+
+// ParseAndroidStrings reads an Android strings.xml resource file. Resources
+// marked with translatable="false" are skipped.
+func ParseAndroidStrings(r io.Reader) (*AndroidStrings, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Android strings: %w", err)
+	}
+
+	var (
+		s        = &AndroidStrings{data: data}
+		d        = xml.NewDecoder(bytes.NewReader(data))
+		depth    int
+		resource xml.StartElement // current top-level resource
+		current  *AndroidString   // string whose content is being read
+		index    int
+		skipped  int64 = -1 // start of the untranslatable resource, if any
+	)
+	for {
+		start := d.InputOffset()
+		token, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Android strings: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 2:
+				resource, index = token, 0
+				if xmlAttr(token, "translatable") == "false" {
+					skipped = start
+					continue
+				}
+				if token.Name.Local == "string" {
+					current = &AndroidString{Name: xmlAttr(token, "name"), Kind: "string", start: d.InputOffset()}
+				}
+			case depth == 3 && skipped < 0 && token.Name.Local == "item" &&
+				(resource.Name.Local == "plurals" || resource.Name.Local == "string-array"):
+				current = &AndroidString{
+					Name:      xmlAttr(resource, "name"),
+					Kind:      resource.Name.Local,
+					Quantity:  xmlAttr(token, "quantity"),
+					Index:     index,
+					start:     d.InputOffset(),
+					elemStart: start,
+				}
+				index++
+			}
+		case xml.EndElement:
+			depth--
+			// Nested markup ends deeper, strings at 1 and items at 2.
+			if current != nil && (depth == 1 || depth == 2 && current.Kind != "string") {
+				current.end = start
+				current.elemEnd = d.InputOffset()
+				current.Value = string(data[current.start:current.end])
+				current.original = current.Value
+				s.Strings = append(s.Strings, current)
+				current = nil
+			}
+			if depth == 1 && skipped >= 0 {
+				s.removed = append(s.removed, [2]int64{skipped, d.InputOffset()})
+				skipped = -1
+			}
+		}
+	}
+	return s, nil
+}
+
+// WriteTo writes the resource file with the changed values spliced in and
+// without the untranslatable resources, as expected of a translated
+// resource directory.
+func (s *AndroidStrings) WriteTo(w io.Writer) (int64, error) {
+	type replacement struct {
+		start, end int64
+		text       string
+	}
+	var replacements []replacement
+	for _, span := range s.removed {
+		// Remove the whole line if the resource is the only thing on it.
+		start, end := span[0], span[1]
+		lineStart := int64(bytes.LastIndexByte(s.data[:start], '\n'))
+		if lineStart >= 0 && len(bytes.TrimSpace(s.data[lineStart:start])) == 0 &&
+			bytes.HasPrefix(s.data[end:], []byte("\n")) {
+			start = lineStart
+		}
+		replacements = append(replacements, replacement{start, end, ""})
+	}
+	edited := make(map[*AndroidString]bool)
+	for _, edit := range s.plurals {
+		items := make([]string, len(edit.items))
+		for i, item := range edit.items {
+			items[i] = item.element(s.data)
+			edited[item] = true
+		}
+		replacements = append(replacements, replacement{edit.start, edit.end, strings.Join(items, edit.sep)})
+	}
+	for _, str := range s.Strings {
+		if str.Value != str.original && !edited[str] {
+			replacements = append(replacements, replacement{str.start, str.end, str.Value})
+		}
+	}
+	slices.SortFunc(replacements, func(a, b replacement) int {
+		return int(a.start - b.start)
+	})
+
+	var (
+		buf  bytes.Buffer
+		last int64
+	)
+	for _, r := range replacements {
+		buf.Write(s.data[last:r.start])
+		buf.WriteString(r.text)
+		last = r.end
+	}
+	buf.Write(s.data[last:])
+	return buf.WriteTo(w)
+}
+
+// TranslateAndroidStrings translates all strings, plurals and string arrays
+// of the resource file. Android escapes are handled, format arguments such
+// as %1$s and <xliff:g> elements are protected, and other markup is kept
+// with XML tag handling. References to other resources, such as
+// @string/app_name, are left as is, and strings enclosed in double quotes
+// stay enclosed in them. Plurals are given the items of the plural categories
+// of the target language, those missing from the source being translated
+// from its "other" item.
+func (t *Translator) TranslateAndroidStrings(ctx context.Context, strs *AndroidStrings, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+	tagHandling := "xml"
+	o.TagHandling = &tagHandling
+
+	segments := make([]string, len(strs.Strings))
+	tokens := make([][]string, len(strs.Strings))
+	quoted := make([]bool, len(strs.Strings))
+	for i, str := range strs.Strings {
+		if isAndroidReference(str.Value) {
+			continue
+		}
+		var value string
+		value, quoted[i] = unquoteAndroid(str.Value)
+		segments[i], tokens[i] = protectTokens(unescapeAndroid(value), false, androidTokenLen)
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(protectOptions(o, false)))
+	if err != nil {
+		return err
+	}
+	for i, str := range strs.Strings {
+		if isAndroidReference(str.Value) {
+			continue
+		}
+		result, err := restorePlaceholders(results[i], tokens[i], false)
+		if err != nil {
+			return fmt.Errorf("error translating %s: %w", str.Name, err)
+		}
+		if quoted[i] {
+			str.Value = `"` + escapeAndroid(result) + `"`
+		} else {
+			str.Value = escapeAndroid(result)
+		}
+	}
+	strs.adjustPlurals(pluralCategories(targetLang))
+	return nil
+}
+
+// adjustPlurals gives every <plurals> resource the items of the plural
+// categories, unless they are nil. Missing items are copied from the
+// "other" one, and items of other categories are left out.
+func (s *AndroidStrings) adjustPlurals(categories []string) {
+	if categories == nil {
+		return
+	}
+
+	var strs []*AndroidString
+	for i := 0; i < len(s.Strings); {
+		str := s.Strings[i]
+		if str.Kind != "plurals" {
+			strs = append(strs, str)
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(s.Strings) && s.Strings[j].Kind == "plurals" && s.Strings[j].Name == str.Name {
+			j++
+		}
+		items := s.Strings[i:j]
+		i = j
+
+		present := make([]string, len(items))
+		for k, item := range items {
+			present[k] = item.Quantity
+		}
+		if slices.Equal(present, categories) {
+			strs = append(strs, items...)
+			continue
+		}
+
+		template := items[slices.Index(present, pluralTemplate(present))]
+		edit := androidPluralEdit{start: items[0].elemStart, end: items[len(items)-1].elemEnd}
+		if len(items) > 1 {
+			edit.sep = string(s.data[items[0].elemEnd:items[1].elemStart])
+		} else {
+			// Put further items on lines of their own, indented as the line
+			// of the first one.
+			lineStart := bytes.LastIndexByte(s.data[:edit.start], '\n') + 1
+			edit.sep = "\n" + leadingSpace(string(s.data[lineStart:edit.start]))
+		}
+		for _, category := range categories {
+			k := slices.Index(present, category)
+			if k >= 0 {
+				edit.items = append(edit.items, items[k])
+				continue
+			}
+			item := *template
+			item.Quantity = category
+			edit.items = append(edit.items, &item)
+		}
+		s.plurals = append(s.plurals, edit)
+		strs = append(strs, edit.items...)
+	}
+	s.Strings = strs
+}
+
+// element returns the raw <item> element of a plural item, with its
+// quantity and value.
+func (str *AndroidString) element(data []byte) string {
+	startTag := string(data[str.elemStart:str.start])
+	startTag = androidQuantityRegexp.ReplaceAllString(startTag, `quantity="`+str.Quantity+`"`)
+	return startTag + str.Value + string(data[str.end:str.elemEnd])
+}
+
+var androidQuantityRegexp = regexp.MustCompile(`quantity\s*=\s*("[^"]*"|'[^']*')`)
+
+func androidTokenLen(s string) int {
+	if strings.HasPrefix(s, "<xliff:g") {
+		if end := strings.Index(s, "</xliff:g>"); end >= 0 {
+			return end + len("</xliff:g>")
+		}
+	}
+	return placeholderLen(s)
+}
+
+// isAndroidReference reports whether an Android string is a reference to
+// another resource, such as @string/app_name or ?attr/colorPrimary, rather
+// than text.
+func isAndroidReference(s string) bool {
+	return strings.HasPrefix(s, "@") || strings.HasPrefix(s, "?")
+}
+
+// unquoteAndroid returns the content of an Android string enclosed in double
+// quotes, which keep its whitespace, and whether it is enclosed in them.
+func unquoteAndroid(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s, false
+	}
+	inner := s[1 : len(s)-1]
+	// The closing quote must not be escaped.
+	trailing := len(inner) - len(strings.TrimRight(inner, `\`))
+	if trailing%2 == 1 {
+		return s, false
+	}
+	return inner, true
+}
+
+// unescapeAndroid resolves the backslash escapes of an Android string,
+// leaving XML markup and entities as is.
+func unescapeAndroid(s string) string {
+	return androidUnescaper.Replace(s)
+}
+
+// escapeAndroid applies the backslash escapes of an Android string to the
+// text outside of markup.
+func escapeAndroid(s string) string {
+	var sb strings.Builder
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			i = len(s)
+		}
+		sb.WriteString(androidEscaper.Replace(s[:i]))
+		s = s[i:]
+
+		j := strings.IndexByte(s, '>')
+		if j < 0 {
+			j = len(s) - 1
+		}
+		sb.WriteString(s[:j+1])
+		s = s[j+1:]
+	}
+
+	escaped := sb.String()
+	if strings.HasPrefix(escaped, "@") || strings.HasPrefix(escaped, "?") {
+		escaped = `\` + escaped
+	}
+	return escaped
+}
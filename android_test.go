@@ -0,0 +1,199 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAndroidValuesDir(t *testing.T) {
+	tests := map[string]string{
+		"de":      "values-de",
+		"PT-BR":   "values-pt-rBR",
+		"zh":      "values-zh-rCN",
+		"ZH-HANT": "values-zh-rTW",
+		"ES-419":  "values-b+es+419",
+		"HE":      "values-iw",
+	}
+	for lang, dir := range tests {
+		assert.Equal(t, dir, AndroidValuesDir(lang), lang)
+	}
+}
+
+func TestAndroidStringsRoundTrip(t *testing.T) {
+	source := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- App name -->
+    <string name="app_name" translatable="false">Demo</string>
+    <string name="greeting">Hello, <b>%1$s</b>!</string>
+</resources>
+`
+	strs, err := ParseAndroidStrings(strings.NewReader(source))
+	if assert.NoError(t, err) && assert.Len(t, strs.Strings, 1) {
+		assert.Equal(t, "greeting", strs.Strings[0].Name)
+		assert.Equal(t, "Hello, <b>%1$s</b>!", strs.Strings[0].Value)
+
+		var buf bytes.Buffer
+		_, err := strs.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Replace(source, "    <string name=\"app_name\" translatable=\"false\">Demo</string>\n", "", 1), buf.String())
+	}
+}
+
+func TestTranslateAndroidStrings(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := `<resources>
+    <string name="welcome">Don\'t miss <xliff:g id="count">%d</xliff:g> "deals"</string>
+    <plurals name="files">
+        <item quantity="one">%d file</item>
+        <item quantity="other">%d files</item>
+    </plurals>
+    <string-array name="planets">
+        <item>Mercury</item>
+        <item>Venus</item>
+    </string-array>
+</resources>
+`
+	strs, err := ParseAndroidStrings(strings.NewReader(source))
+	if !assert.NoError(t, err) || !assert.Len(t, strs.Strings, 5) {
+		return
+	}
+	assert.Equal(t, "other", strs.Strings[2].Quantity)
+	assert.Equal(t, 1, strs.Strings[4].Index)
+
+	err = translator.TranslateAndroidStrings(context.Background(), strs, "de")
+	if assert.NoError(t, err) {
+		var buf bytes.Buffer
+		_, err := strs.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, `<resources>
+    <string name="welcome">[DE]Don\'t miss <xliff:g id="count">%d</xliff:g> \"deals\"</string>
+    <plurals name="files">
+        <item quantity="one">[DE]%d file</item>
+        <item quantity="other">[DE]%d files</item>
+    </plurals>
+    <string-array name="planets">
+        <item>[DE]Mercury</item>
+        <item>[DE]Venus</item>
+    </string-array>
+</resources>
+`, buf.String())
+	}
+}
+
+func TestTranslateAndroidStringsPlurals(t *testing.T) {
+	source := `<resources>
+    <plurals name="files">
+        <item quantity="one">%d file</item>
+        <item quantity="other">%d files</item>
+    </plurals>
+    <plurals name="days"><item quantity="other">%d days</item></plurals>
+</resources>
+`
+	tests := []struct {
+		targetLang string
+		expected   string
+	}{
+		{"pl", `<resources>
+    <plurals name="files">
+        <item quantity="one">[PL]%d file</item>
+        <item quantity="few">[PL]%d files</item>
+        <item quantity="many">[PL]%d files</item>
+        <item quantity="other">[PL]%d files</item>
+    </plurals>
+    <plurals name="days"><item quantity="one">[PL]%d days</item>
+    <item quantity="few">[PL]%d days</item>
+    <item quantity="many">[PL]%d days</item>
+    <item quantity="other">[PL]%d days</item></plurals>
+</resources>
+`},
+		{"ja", `<resources>
+    <plurals name="files">
+        <item quantity="other">[JA]%d files</item>
+    </plurals>
+    <plurals name="days"><item quantity="other">[JA]%d days</item></plurals>
+</resources>
+`},
+	}
+	for _, tt := range tests {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+		strs, err := ParseAndroidStrings(strings.NewReader(source))
+		if !assert.NoError(t, err) {
+			return
+		}
+		err = translator.TranslateAndroidStrings(context.Background(), strs, tt.targetLang)
+		if assert.NoError(t, err) {
+			var buf bytes.Buffer
+			_, err := strs.WriteTo(&buf)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.String(), tt.targetLang)
+		}
+		var quantities []string
+		for _, str := range strs.Strings {
+			if str.Name == "files" {
+				quantities = append(quantities, str.Quantity)
+			}
+		}
+		assert.Equal(t, pluralCategories(tt.targetLang), quantities)
+	}
+}
+
+func TestTranslateAndroidStringsQuotesAndReferences(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := `<resources>
+    <string name="title">@string/app_name</string>
+    <string name="color">?attr/colorPrimary</string>
+    <string name="padded">"  quoted  "</string>
+    <string name="literal">\@home</string>
+</resources>
+`
+	strs, err := ParseAndroidStrings(strings.NewReader(source))
+	if !assert.NoError(t, err) || !assert.NoError(t, translator.TranslateAndroidStrings(context.Background(), strs, "de")) {
+		return
+	}
+	var buf bytes.Buffer
+	_, err = strs.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `<resources>
+    <string name="title">@string/app_name</string>
+    <string name="color">?attr/colorPrimary</string>
+    <string name="padded">"[DE]  quoted  "</string>
+    <string name="literal">[DE]@home</string>
+</resources>
+`, buf.String())
+	assert.Equal(t, int64(1), server.requests.Load())
+	assert.Equal(t, []any{"  quoted  ", "@home"}, server.lastRequest()["text"])
+}
+
+func TestUnquoteAndroid(t *testing.T) {
+	for s, expected := range map[string]struct {
+		inner  string
+		quoted bool
+	}{
+		`"  a  "`: {"  a  ", true},
+		`""`:      {"", true},
+		`"a\\"`:   {`a\\`, true},
+		`"a\"`:    {`"a\"`, false},
+		`"`:       {`"`, false},
+		`a "b" c`: {`a "b" c`, false},
+	} {
+		inner, quoted := unquoteAndroid(s)
+		assert.Equal(t, expected.inner, inner, s)
+		assert.Equal(t, expected.quoted, quoted, s)
+	}
+}
+
+func TestEscapeAndroid(t *testing.T) {
+	assert.Equal(t, `\@string/name`, escapeAndroid("@string/name"))
+	assert.Equal(t, `It\'s <a href="x">here</a>\nnow`, escapeAndroid("It's <a href=\"x\">here</a>\nnow"))
+	assert.Equal(t, `a < b`, escapeAndroid("a < b"))
+}
@@ -0,0 +1,486 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// appleLocales maps DeepL target languages to Apple locale identifiers
+// where the generic rule does not apply.
+var appleLocales = map[string]string{
+	"ZH":      "zh-Hans",
+	"ZH-HANS": "zh-Hans",
+	"ZH-HANT": "zh-Hant",
+	"EN-US":   "en",
+}
+
+// AppleLocale returns the Apple locale identifier for a DeepL target
+// language, e.g. "de", "pt-BR" or "zh-Hans".
+func AppleLocale(targetLang string) string {
+	lang := strings.ToUpper(targetLang)
+	if locale, ok := appleLocales[lang]; ok {
+		return locale
+	}
+	base, region, ok := strings.Cut(lang, "-")
+	if !ok {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "-" + region
+}
+
+// AppleLprojDir returns the name of the localization directory for a DeepL
+// target language, e.g. "de.lproj" or "zh-Hans.lproj".
+func AppleLprojDir(targetLang string) string {
+	return AppleLocale(targetLang) + ".lproj"
+}
+
+// AppleStrings is a parsed .strings file. Apart from the changed values, the
+// file is written back exactly as it was read, in its original encoding.
+type AppleStrings struct {
+	Strings []*AppleString
+
+	data  string
+	utf16 binary.ByteOrder // nil for UTF-8
+}
+
+// AppleString is a single entry of a .strings file.
+type AppleString struct {
+	Key     string
+	Value   string
+	Comment string // the preceding comment, if any
+
+	original   string
+	start, end int // offsets of the quoted value
+}
+
+// ParseAppleStrings reads a .strings file encoded in UTF-8 or, with a byte
+// order mark, UTF-16.
+func ParseAppleStrings(r io.Reader) (*AppleStrings, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Apple strings: %w", err)
+	}
+
+	s := &AppleStrings{}
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}):
+		s.utf16 = binary.LittleEndian
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		s.utf16 = binary.BigEndian
+	}
+	if s.utf16 != nil {
+		units := make([]uint16, (len(raw)-2)/2)
+		for i := range units {
+			units[i] = s.utf16.Uint16(raw[2+2*i:])
+		}
+		s.data = string(utf16.Decode(units))
+	} else {
+		s.data = string(raw)
+	}
+
+	p := &appleStringsParser{data: s.data}
+	for {
+		comment, err := p.skip()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) {
+			break
+		}
+
+		key, _, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.skip(); err != nil {
+			return nil, err
+		}
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		if _, err := p.skip(); err != nil {
+			return nil, err
+		}
+		start := p.pos
+		value, quoted, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		if !quoted {
+			return nil, fmt.Errorf("offset %d: value of %q must be quoted", start, key)
+		}
+		end := p.pos
+		if _, err := p.skip(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(';'); err != nil {
+			return nil, err
+		}
+
+		s.Strings = append(s.Strings, &AppleString{
+			Key:      key,
+			Value:    value,
+			Comment:  comment,
+			original: value,
+			start:    start,
+			end:      end,
+		})
+	}
+	return s, nil
+}
+
+// WriteTo writes the file with the changed values spliced in.
+func (s *AppleStrings) WriteTo(w io.Writer) (int64, error) {
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, str := range s.Strings {
+		if str.Value == str.original {
+			continue
+		}
+		sb.WriteString(s.data[last:str.start])
+		sb.WriteString(quoteAppleString(str.Value))
+		last = str.end
+	}
+	sb.WriteString(s.data[last:])
+
+	if s.utf16 == nil {
+		n, err := io.WriteString(w, sb.String())
+		return int64(n), err
+	}
+	units := utf16.Encode([]rune(sb.String()))
+	buf := make([]byte, 2+2*len(units))
+	s.utf16.PutUint16(buf, 0xFEFF)
+	for i, unit := range units {
+		s.utf16.PutUint16(buf[2+2*i:], unit)
+	}
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// TranslateAppleStrings translates all values of a .strings file, with
+// format specifiers such as %@ and %1$lld protected.
+func (t *Translator) TranslateAppleStrings(ctx context.Context, strs *AppleStrings, targetLang string, opts ...TranslateOption) error {
+	values := make([]*string, len(strs.Strings))
+	for i, str := range strs.Strings {
+		values[i] = &str.Value
+	}
+	return t.translateProtectedValues(ctx, values, targetLang, opts...)
+}
+
+// XCStrings is a parsed Xcode string catalog (.xcstrings), which holds the
+// strings of all languages in one file.
+type XCStrings struct {
+	SourceLanguage string
+
+	root   *yaml.Node
+	source []byte
+}
+
+// ParseXCStrings reads an Xcode string catalog.
+func ParseXCStrings(r io.Reader) (*XCStrings, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading string catalog: %w", err)
+	}
+	root, err := decodeLocaleFile(data, LocaleFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("invalid string catalog")
+	}
+
+	c := &XCStrings{root: root, source: data}
+	if lang := yamlMappingValue(root, "sourceLanguage"); lang != nil {
+		c.SourceLanguage = lang.Value
+	}
+	return c, nil
+}
+
+// WriteTo writes the string catalog in the style it was read.
+func (c *XCStrings) WriteTo(w io.Writer) (int64, error) {
+	data, err := encodeJSONFile(c.root, c.source)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// TranslateXCStrings adds a localization for the target language to every
+// string of the catalog that has none yet and is not marked with
+// shouldTranslate=false. The source localization is mirrored, including
+// plural and device variations and substitutions, and the new string units
+// are marked as needing review. Plural variations are given the plural
+// categories of the target language, those missing from the source being
+// translated from its "other" variation.
+func (t *Translator) TranslateXCStrings(ctx context.Context, catalog *XCStrings, targetLang string, opts ...TranslateOption) error {
+	locale := AppleLocale(targetLang)
+
+	strs := yamlMappingValue(catalog.root, "strings")
+	if strs == nil {
+		return nil
+	}
+
+	// The new localizations are only added to the catalog once they have
+	// been translated.
+	type insertion struct {
+		entry, target *yaml.Node
+	}
+	var (
+		values     []*string
+		insertions []insertion
+	)
+	for i := 0; i+1 < len(strs.Content); i += 2 {
+		key, entry := strs.Content[i].Value, strs.Content[i+1]
+		if entry.Kind != yaml.MappingNode {
+			continue
+		}
+		if should := yamlMappingValue(entry, "shouldTranslate"); should != nil && should.Value == "false" {
+			continue
+		}
+
+		localizations := yamlMappingValue(entry, "localizations")
+		if localizations != nil && yamlMappingValue(localizations, locale) != nil {
+			continue
+		}
+
+		// Strings without a source localization use their key as text.
+		var target *yaml.Node
+		if source := yamlMappingValue(localizations, catalog.SourceLanguage); source != nil {
+			target = cloneYAMLNode(source)
+		} else {
+			target = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				yamlString("stringUnit"), {Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+					yamlString("state"), yamlString(""),
+					yamlString("value"), yamlString(key),
+				}},
+			}}
+		}
+		adjustXCStringPlurals(target, pluralCategories(targetLang))
+		walkXCStringUnits(target, func(unit *yaml.Node) {
+			if state := yamlMappingValue(unit, "state"); state != nil {
+				state.Value = "needs_review"
+			}
+			if value := yamlMappingValue(unit, "value"); value != nil {
+				values = append(values, &value.Value)
+			}
+		})
+		insertions = append(insertions, insertion{entry, target})
+	}
+
+	if err := t.translateProtectedValues(ctx, values, targetLang, opts...); err != nil {
+		return err
+	}
+
+	for _, ins := range insertions {
+		localizations := yamlMappingValue(ins.entry, "localizations")
+		if localizations == nil {
+			localizations = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			ins.entry.Content = append(ins.entry.Content, yamlString("localizations"), localizations)
+		}
+
+		// Localizations are kept sorted, as Xcode does.
+		at := 0
+		for at < len(localizations.Content) && localizations.Content[at].Value < locale {
+			at += 2
+		}
+		localizations.Content = slices.Insert(localizations.Content, at, yamlString(locale), ins.target)
+	}
+	return nil
+}
+
+// adjustXCStringPlurals sets the plural variations below node to the
+// categories, unless they are nil.
+func adjustXCStringPlurals(node *yaml.Node, categories []string) {
+	if node.Kind != yaml.MappingNode || categories == nil {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := node.Content[i+1]
+		if node.Content[i].Value != "plural" || value.Kind != yaml.MappingNode {
+			adjustXCStringPlurals(value, categories)
+			continue
+		}
+
+		present := make([]string, 0, len(value.Content)/2)
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			present = append(present, value.Content[j].Value)
+		}
+		template := yamlMappingValue(value, pluralTemplate(present))
+		if template == nil {
+			continue
+		}
+		content := make([]*yaml.Node, 0, 2*len(categories))
+		for _, category := range categories {
+			variation := yamlMappingValue(value, category)
+			if variation == nil {
+				variation = cloneYAMLNode(template)
+			}
+			content = append(content, yamlString(category), variation)
+		}
+		value.Content = content
+	}
+}
+
+// walkXCStringUnits calls fn for every string unit below node.
+func walkXCStringUnits(node *yaml.Node, fn func(*yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "stringUnit" {
+			fn(node.Content[i+1])
+			continue
+		}
+		walkXCStringUnits(node.Content[i+1], fn)
+	}
+}
+
+// translateProtectedValues translates the plain text values in place, with
+// placeholders protected.
+func (t *Translator) translateProtectedValues(ctx context.Context, values []*string, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	segments := make([]string, len(values))
+	tokens := make([][]string, len(values))
+	for i, value := range values {
+		segments[i], tokens[i] = protectPlaceholders(*value, true)
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(protectOptions(o, true)))
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if *value, err = restorePlaceholders(results[i], tokens[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// appleStringsParser is a scanner for the old-style property list syntax of
+// .strings files.
+type appleStringsParser struct {
+	data string
+	pos  int
+}
+
+// skip skips whitespace and comments, returning the text of the last
+// comment skipped.
+func (p *appleStringsParser) skip() (string, error) {
+	var comment string
+	for p.pos < len(p.data) {
+		rest := p.data[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("offset %d: unterminated comment", p.pos)
+			}
+			comment = strings.TrimSpace(rest[2 : end+2])
+			p.pos += end + 4
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			comment = strings.TrimSpace(rest[2:end])
+			p.pos += end
+		case strings.ContainsRune(" \t\r\n", rune(rest[0])) || strings.HasPrefix(rest, "\uFEFF"):
+			_, size := utf8.DecodeRuneInString(rest)
+			p.pos += size
+		default:
+			return comment, nil
+		}
+	}
+	return comment, nil
+}
+
+func (p *appleStringsParser) expect(c byte) error {
+	if p.pos >= len(p.data) || p.data[p.pos] != c {
+		return fmt.Errorf("offset %d: expected %q", p.pos, c)
+	}
+	p.pos++
+	return nil
+}
+
+// token reads a quoted string or an unquoted word.
+func (p *appleStringsParser) token() (string, bool, error) {
+	if p.pos >= len(p.data) {
+		return "", false, io.ErrUnexpectedEOF
+	}
+	if p.data[p.pos] != '"' {
+		start := p.pos
+		for p.pos < len(p.data) && !strings.ContainsRune(" \t\r\n=;/\"", rune(p.data[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", false, fmt.Errorf("offset %d: unexpected %q", p.pos, p.data[p.pos])
+		}
+		return p.data[start:p.pos], false, nil
+	}
+
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.data); p.pos++ {
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), true, nil
+		case c == '\\' && p.pos+1 < len(p.data):
+			p.pos++
+			switch e := p.data[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'U', 'u':
+				if p.pos+4 < len(p.data) {
+					if r, err := strconv.ParseUint(p.data[p.pos+1:p.pos+5], 16, 16); err == nil {
+						sb.WriteRune(rune(r))
+						p.pos += 4
+						continue
+					}
+				}
+				sb.WriteByte(e)
+			default: // \", \\ and anything unknown
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false, io.ErrUnexpectedEOF
+}
+
+var appleStringsEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+)
+
+func quoteAppleString(s string) string {
+	return `"` + appleStringsEscaper.Replace(s) + `"`
+}
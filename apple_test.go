@@ -0,0 +1,301 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppleLprojDir(t *testing.T) {
+	tests := map[string]string{
+		"de":      "de.lproj",
+		"EN-US":   "en.lproj",
+		"en-gb":   "en-GB.lproj",
+		"PT-BR":   "pt-BR.lproj",
+		"ZH":      "zh-Hans.lproj",
+		"ZH-HANT": "zh-Hant.lproj",
+	}
+	for lang, dir := range tests {
+		assert.Equal(t, dir, AppleLprojDir(lang), lang)
+	}
+}
+
+func TestTranslateAppleStrings(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := `/* Greeting shown on launch */
+"greeting" = "Hello, %@!";
+
+// Unquoted keys are allowed
+count = "%1$lld \"new\" items";
+`
+	strs, err := ParseAppleStrings(strings.NewReader(source))
+	if !assert.NoError(t, err) || !assert.Len(t, strs.Strings, 2) {
+		return
+	}
+	assert.Equal(t, "Greeting shown on launch", strs.Strings[0].Comment)
+	assert.Equal(t, "count", strs.Strings[1].Key)
+	assert.Equal(t, `%1$lld "new" items`, strs.Strings[1].Value)
+
+	err = translator.TranslateAppleStrings(context.Background(), strs, "de")
+	if assert.NoError(t, err) {
+		var buf bytes.Buffer
+		_, err := strs.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, `/* Greeting shown on launch */
+"greeting" = "[DE]Hello, %@!";
+
+// Unquoted keys are allowed
+count = "[DE]%1$lld \"new\" items";
+`, buf.String())
+	}
+}
+
+func TestParseAppleStringsErrors(t *testing.T) {
+	for _, source := range []string{
+		"\"a\" = \"b\";\n/*",
+		"/* never closed",
+		"\"a\" /* */ = /* \"b\";",
+		"\"a\" = b;",
+		"\"a\" = \"b\"",
+	} {
+		_, err := ParseAppleStrings(strings.NewReader(source))
+		assert.Error(t, err, source)
+	}
+}
+
+func TestAppleStringsUTF16(t *testing.T) {
+	encode := func(s string) []byte {
+		units := utf16.Encode([]rune("\uFEFF" + s))
+		buf := make([]byte, 2*len(units))
+		for i, unit := range units {
+			buf[2*i], buf[2*i+1] = byte(unit), byte(unit>>8)
+		}
+		return buf
+	}
+
+	strs, err := ParseAppleStrings(bytes.NewReader(encode(`"title" = "Café";`)))
+	if assert.NoError(t, err) && assert.Len(t, strs.Strings, 1) {
+		assert.Equal(t, "Café", strs.Strings[0].Value)
+
+		strs.Strings[0].Value = "Kaffee"
+		var buf bytes.Buffer
+		_, err := strs.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, encode(`"title" = "Kaffee";`), buf.Bytes())
+	}
+}
+
+func TestTranslateXCStrings(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld files" : {
+      "localizations" : {
+        "en" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld file"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld files"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Done" : {
+
+    },
+    "Internal" : {
+      "shouldTranslate" : false
+    }
+  },
+  "version" : "1.0"
+}
+`
+	catalog, err := ParseXCStrings(strings.NewReader(source))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "en", catalog.SourceLanguage)
+
+	err = translator.TranslateXCStrings(context.Background(), catalog, "de")
+	if assert.NoError(t, err) {
+		var buf bytes.Buffer
+		_, err := catalog.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld files" : {
+      "localizations" : {
+        "de" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "needs_review",
+                  "value" : "[DE]%lld file"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "needs_review",
+                  "value" : "[DE]%lld files"
+                }
+              }
+            }
+          }
+        },
+        "en" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld file"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld files"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Done" : {
+      "localizations" : {
+        "de" : {
+          "stringUnit" : {
+            "state" : "needs_review",
+            "value" : "[DE]Done"
+          }
+        }
+      }
+    },
+    "Internal" : {
+      "shouldTranslate" : false
+    }
+  },
+  "version" : "1.0"
+}
+`, buf.String())
+	}
+}
+
+func TestTranslateXCStringsFailure(t *testing.T) {
+	source := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "Done" : {
+
+    },
+    "Hello" : {
+      "localizations" : {
+        "en" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "Hello"
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+	catalog, err := ParseXCStrings(strings.NewReader(source))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var expected bytes.Buffer
+	_, err = catalog.WriteTo(&expected)
+	assert.NoError(t, err)
+
+	// Nothing is added to the catalog if the translation fails.
+	translator := NewTranslator("", WithBaseURL("http://127.0.0.1:0/v2"))
+	assert.Error(t, translator.TranslateXCStrings(context.Background(), catalog, "de"))
+	var buf bytes.Buffer
+	_, err = catalog.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), buf.String())
+}
+
+func TestTranslateXCStringsPlurals(t *testing.T) {
+	source := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld files" : {
+      "localizations" : {
+        "en" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld file"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld files"
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+	tests := map[string]map[string]string{
+		"pl": {"one": "[PL]%lld file", "few": "[PL]%lld files", "many": "[PL]%lld files", "other": "[PL]%lld files"},
+		"ja": {"other": "[JA]%lld files"},
+	}
+	for targetLang, expected := range tests {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+		catalog, err := ParseXCStrings(strings.NewReader(source))
+		if !assert.NoError(t, err) || !assert.NoError(t, translator.TranslateXCStrings(context.Background(), catalog, targetLang)) {
+			return
+		}
+
+		strs := yamlMappingValue(catalog.root, "strings")
+		localization := yamlMappingValue(yamlMappingValue(yamlMappingValue(strs, "%lld files"), "localizations"), targetLang)
+		plural := yamlMappingValue(yamlMappingValue(localization, "variations"), "plural")
+		var categories []string
+		actual := make(map[string]string)
+		for i := 0; i+1 < len(plural.Content); i += 2 {
+			categories = append(categories, plural.Content[i].Value)
+			unit := yamlMappingValue(plural.Content[i+1], "stringUnit")
+			actual[plural.Content[i].Value] = yamlMappingValue(unit, "value").Value
+		}
+		assert.Equal(t, pluralCategories(targetLang), categories)
+		assert.Equal(t, expected, actual)
+	}
+}
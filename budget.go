@@ -0,0 +1,190 @@
+package deeplx_translator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type BudgetPeriod uint8
+
+const (
+	BudgetDaily BudgetPeriod = iota + 1
+	BudgetMonthly
+)
+
+func (p BudgetPeriod) String() string {
+	switch p {
+	case BudgetDaily:
+		return "daily"
+	case BudgetMonthly:
+		return "monthly"
+	default:
+		return fmt.Sprintf("BudgetPeriod(%d)", p)
+	}
+}
+
+// start returns the start of the period containing t, in UTC.
+func (p BudgetPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	if p == BudgetDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// BudgetExceededError is returned for a request that would take a tenant's
+// billed characters past one of its limits. The request is not sent.
+type BudgetExceededError struct {
+	Tenant    string
+	Period    BudgetPeriod
+	Limit     int64
+	Used      int64
+	Requested int64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded for tenant %q: %d of %d characters used, %d requested",
+		e.Period, e.Tenant, e.Used, e.Limit, e.Requested)
+}
+
+// Budget caps the characters billed per tenant, by day and by month in UTC.
+// Requests made without WithTenant count towards the tenant "". It is safe
+// for concurrent use, and may be shared by several Translators.
+type Budget struct {
+	mu      sync.Mutex
+	limits  map[BudgetPeriod]int64
+	tenants map[string]*budgetTenant
+	now     func() time.Time
+}
+
+type budgetTenant struct {
+	limits map[BudgetPeriod]int64 // overrides, if any
+	used   map[BudgetPeriod]int64
+	starts map[BudgetPeriod]time.Time
+}
+
+// BudgetOption is a functional option for configuring the Budget.
+type BudgetOption func(*Budget)
+
+// WithDailyLimit limits the characters billed per tenant and day.
+func WithDailyLimit(characters int64) BudgetOption {
+	return func(b *Budget) {
+		b.limits[BudgetDaily] = characters
+	}
+}
+
+// WithMonthlyLimit limits the characters billed per tenant and month.
+func WithMonthlyLimit(characters int64) BudgetOption {
+	return func(b *Budget) {
+		b.limits[BudgetMonthly] = characters
+	}
+}
+
+// NewBudget creates a new budget. Without limits, it only counts.
+func NewBudget(opts ...BudgetOption) *Budget {
+	b := &Budget{
+		limits:  make(map[BudgetPeriod]int64),
+		tenants: make(map[string]*budgetTenant),
+		now:     time.Now,
+	}
+	for _, option := range opts {
+		option(b)
+	}
+	return b
+}
+
+// WithBudget makes the Translator count the characters it sends against the
+// budget, and refuse requests exceeding it with a *BudgetExceededError.
+func WithBudget(budget *Budget) TranslatorOption {
+	return func(t *Translator) {
+		t.budget = budget
+	}
+}
+
+// SetLimit sets the limit of a single tenant for the period, overriding the
+// budget's default. A limit of 0 or less removes the tenant's limit.
+func (b *Budget) SetLimit(tenant string, period BudgetPeriod, characters int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tenant(tenant).limits[period] = characters
+}
+
+// Used returns the characters billed to the tenant in the current period.
+func (b *Budget) Used(tenant string, period BudgetPeriod) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tt, ok := b.tenants[tenant]
+	if !ok || !tt.starts[period].Equal(period.start(b.now())) {
+		return 0
+	}
+	return tt.used[period]
+}
+
+// reserve counts characters against the tenant's budget, unless that would
+// exceed one of its limits. It returns the time of the reservation, which is
+// passed to release.
+func (b *Budget) reserve(tenant string, characters int64) (time.Time, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	tt := b.tenant(tenant)
+	tt.roll(now)
+	for _, period := range []BudgetPeriod{BudgetDaily, BudgetMonthly} {
+		limit, ok := tt.limits[period]
+		if !ok {
+			limit = b.limits[period]
+		}
+		if limit > 0 && tt.used[period]+characters > limit {
+			return time.Time{}, &BudgetExceededError{
+				Tenant:    tenant,
+				Period:    period,
+				Limit:     limit,
+				Used:      tt.used[period],
+				Requested: characters,
+			}
+		}
+	}
+	for _, period := range []BudgetPeriod{BudgetDaily, BudgetMonthly} {
+		tt.used[period] += characters
+	}
+	return now, nil
+}
+
+// release gives back characters reserved at the given time for a request
+// that failed. Periods that have ended since are left alone.
+func (b *Budget) release(tenant string, characters int64, reserved time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tt := b.tenant(tenant)
+	tt.roll(b.now())
+	for _, period := range []BudgetPeriod{BudgetDaily, BudgetMonthly} {
+		if tt.starts[period].Equal(period.start(reserved)) {
+			tt.used[period] = max(tt.used[period]-characters, 0)
+		}
+	}
+}
+
+func (b *Budget) tenant(name string) *budgetTenant {
+	tt, ok := b.tenants[name]
+	if !ok {
+		tt = &budgetTenant{
+			limits: make(map[BudgetPeriod]int64),
+			used:   make(map[BudgetPeriod]int64),
+			starts: make(map[BudgetPeriod]time.Time),
+		}
+		b.tenants[name] = tt
+	}
+	return tt
+}
+
+// roll resets the usage of periods that have ended.
+func (tt *budgetTenant) roll(now time.Time) {
+	for _, period := range []BudgetPeriod{BudgetDaily, BudgetMonthly} {
+		if start := period.start(now); !tt.starts[period].Equal(start) {
+			tt.starts[period] = start
+			tt.used[period] = 0
+		}
+	}
+}
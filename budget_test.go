@@ -0,0 +1,108 @@
+package deeplx_translator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	server := newMockServer(t)
+
+	now := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	budget := NewBudget(WithDailyLimit(10), WithMonthlyLimit(15))
+	budget.now = func() time.Time { return now }
+	budget.SetLimit("vip", BudgetDaily, 0)
+	budget.SetLimit("vip", BudgetMonthly, 100)
+
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithBudget(budget))
+
+	_, err := translator.TranslateText([]string{"Hello", "world"}, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), budget.Used("", BudgetDaily))
+
+	_, err = translator.TranslateText("!", "DE")
+	var budgetErr *BudgetExceededError
+	if assert.True(t, errors.As(err, &budgetErr)) {
+		assert.Equal(t, BudgetExceededError{Period: BudgetDaily, Limit: 10, Used: 10, Requested: 1}, *budgetErr)
+		assert.EqualError(t, err, `daily budget exceeded for tenant "": 10 of 10 characters used, 1 requested`)
+	}
+	assert.Equal(t, int64(1), server.requests.Load())
+
+	// Tenants have budgets of their own.
+	_, err = translator.TranslateText("Hello, world!", "DE", WithTenant("vip"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(13), budget.Used("vip", BudgetMonthly))
+
+	// The daily limit resets the next day, the monthly one the next month.
+	now = now.Add(time.Hour)
+	assert.Equal(t, int64(0), budget.Used("", BudgetMonthly))
+	for _, text := range []string{"Hello", "world"} {
+		_, err = translator.TranslateText(text, "DE")
+		assert.NoError(t, err)
+	}
+	now = now.Add(24 * time.Hour)
+	_, err = translator.TranslateText("Hello", "DE")
+	assert.NoError(t, err)
+	_, err = translator.TranslateText("Hi", "DE")
+	if assert.True(t, errors.As(err, &budgetErr)) {
+		assert.Equal(t, BudgetMonthly, budgetErr.Period)
+		assert.Equal(t, int64(15), budgetErr.Used)
+	}
+}
+
+func TestBudgetRelease(t *testing.T) {
+	budget := NewBudget(WithDailyLimit(10))
+	translator := NewTranslator("", WithBaseURL("http://127.0.0.1:0/v2"), WithBudget(budget))
+
+	_, err := translator.TranslateText("Hello", "DE")
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), budget.Used("", BudgetDaily))
+
+	// The response is cut short of its announced length.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte(`{"translations":[`))
+	}))
+	defer server.Close()
+
+	translator = NewTranslator("", WithBaseURL(server.URL+"/v2"), WithBudget(budget))
+	_, err = translator.TranslateText("Hello", "DE")
+	assert.ErrorContains(t, err, "error reading response")
+	assert.Equal(t, int64(0), budget.Used("", BudgetDaily))
+}
+
+func TestBudgetPeriods(t *testing.T) {
+	now := time.Date(2024, 5, 30, 23, 59, 0, 0, time.UTC)
+	budget := NewBudget()
+	budget.now = func() time.Time { return now }
+
+	// Reading does not keep track of tenants.
+	assert.Zero(t, budget.Used("reader", BudgetDaily))
+	assert.NotContains(t, budget.tenants, "reader")
+
+	reserved, err := budget.reserve("", 10)
+	assert.NoError(t, err)
+
+	// A reservation released the next day is only given back to the month.
+	now = now.Add(2 * time.Minute)
+	_, err = budget.reserve("", 5)
+	assert.NoError(t, err)
+	budget.release("", 10, reserved)
+	assert.Equal(t, int64(5), budget.Used("", BudgetDaily))
+	assert.Equal(t, int64(5), budget.Used("", BudgetMonthly))
+
+	// A reservation released the next month is not given back at all.
+	reserved, err = budget.reserve("", 3)
+	assert.NoError(t, err)
+	now = now.Add(24 * time.Hour)
+	_, err = budget.reserve("", 4)
+	assert.NoError(t, err)
+	budget.release("", 3, reserved)
+	assert.Equal(t, int64(4), budget.Used("", BudgetDaily))
+	assert.Equal(t, int64(4), budget.Used("", BudgetMonthly))
+}
@@ -0,0 +1,79 @@
+package deeplx_translator
+
+import (
+	"context"
+	"sync"
+)
+
+// requestGroup coalesces identical concurrent requests, so that only the
+// first one is sent and the others share its response.
+//
+// Unlike a plain singleflight, the shared request is detached from the
+// context of the caller that started it, and is only cancelled once every
+// caller waiting for it has given up.
+type requestGroup struct {
+	mu    sync.Mutex
+	calls map[string]*requestCall
+}
+
+type requestCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	body []byte
+	err  error
+}
+
+// do calls fn for key unless a call for the same key is in flight already,
+// and returns the result of whichever call it waited for, and whether that
+// call was shared with another caller.
+func (g *requestGroup) do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, bool, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*requestCall)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &requestCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go func() {
+			c.body, c.err = fn(callCtx)
+			g.forget(key, c)
+			cancel()
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+	shared := ok
+
+	select {
+	case <-c.done:
+		return c.body, shared, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		abandoned := c.waiters == 0
+		if abandoned && g.calls[key] == c {
+			// Later callers must not join the cancelled call.
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		if abandoned {
+			c.cancel()
+		}
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *requestGroup) forget(key string, c *requestCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,112 @@
+package deeplx_translator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waiters returns the number of callers waiting for requests in flight.
+func (g *requestGroup) waiters() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var n int
+	for _, c := range g.calls {
+		n += c.waiters
+	}
+	return n
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	var requests atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"code":200,"data":"Hallo"}`))
+	}))
+	defer server.Close()
+
+	translator := NewTranslator("", WithBaseURL(server.URL))
+
+	const callers = 5
+	var (
+		wg      sync.WaitGroup
+		results = make([]string, callers)
+		errs    = make([]error, callers)
+	)
+	// One caller gives up early, which must not affect the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := range callers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			callCtx := context.Background()
+			if i == 0 {
+				callCtx = ctx
+			}
+			results[i], errs[i] = translator.TranslateTextContext(callCtx, "Hello", "DE")
+		}(i)
+	}
+	waitFor(t, func() bool { return translator.requests.waiters() == callers })
+	cancel()
+	waitFor(t, func() bool { return translator.requests.waiters() == callers-1 })
+	close(release)
+	wg.Wait()
+
+	assert.ErrorIs(t, errs[0], context.Canceled)
+	for i := 1; i < callers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "Hallo", results[i])
+	}
+	assert.Equal(t, int64(1), requests.Load())
+
+	// Different requests are not coalesced, and finished ones not reused.
+	_, err := translator.TranslateText("Hello", "FR")
+	assert.NoError(t, err)
+	_, err = translator.TranslateText("Hello", "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), requests.Load())
+}
+
+func TestRequestCoalescingAbandoned(t *testing.T) {
+	var g requestGroup
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, _, err := g.do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A later call starts afresh rather than joining the cancelled one.
+	body, shared, err := g.do(context.Background(), "key", func(context.Context) ([]byte, error) {
+		return []byte("ok"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.False(t, shared)
+}
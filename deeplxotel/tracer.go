@@ -0,0 +1,92 @@
+// Package deeplxotel adapts OpenTelemetry tracing to the Tracer interface of
+// deeplx_translator.
+package deeplxotel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	deeplx "github.com/xjasonlyu/deeplx-translator"
+)
+
+// ScopeName is the instrumentation scope name of the spans.
+const ScopeName = "github.com/xjasonlyu/deeplx-translator"
+
+// Tracer is a deeplx_translator.Tracer starting OpenTelemetry spans.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer creates a Tracer using the tracer provider, or the global one
+// if provider is nil.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: provider.Tracer(ScopeName)}
+}
+
+// Start starts a span, which is a client span for HTTP requests and an
+// internal one otherwise.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, deeplx.Span) {
+	kind := trace.SpanKindInternal
+	if name == deeplx.SpanHTTP {
+		kind = trace.SpanKindClient
+	}
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(convertAttrs(attrs)...))
+	return ctx, &Span{span: span}
+}
+
+// Span wraps an OpenTelemetry span.
+type Span struct {
+	span trace.Span
+}
+
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	s.span.SetAttributes(convertAttrs(attrs)...)
+}
+
+// End records err, if any, and sets the status of the span before ending it.
+func (s *Span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func convertAttrs(attrs []slog.Attr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, convertAttr(attr))
+	}
+	return kvs
+}
+
+func convertAttr(attr slog.Attr) attribute.KeyValue {
+	v := attr.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return attribute.String(attr.Key, v.String())
+	case slog.KindInt64:
+		return attribute.Int64(attr.Key, v.Int64())
+	case slog.KindUint64:
+		return attribute.Int64(attr.Key, int64(v.Uint64()))
+	case slog.KindFloat64:
+		return attribute.Float64(attr.Key, v.Float64())
+	case slog.KindBool:
+		return attribute.Bool(attr.Key, v.Bool())
+	case slog.KindDuration:
+		return attribute.Int64(attr.Key, v.Duration().Nanoseconds())
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(v.Any()))
+	}
+}
@@ -0,0 +1,117 @@
+package deeplxotel
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	deeplx "github.com/xjasonlyu/deeplx-translator"
+)
+
+func newTestTranslator(t *testing.T, status int) (*deeplx.Translator, *tracetest.InMemoryExporter) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		var req struct {
+			Text []string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var result deeplx.TranslationResultV2
+		for _, text := range req.Text {
+			result.Translations = append(result.Translations, deeplx.TranslationV2{
+				DetectedSourceLanguage: "EN",
+				Text:                   "[DE]" + text,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+	t.Cleanup(server.Close)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	translator := deeplx.NewTranslator("", deeplx.WithBaseURL(server.URL+"/v2"),
+		deeplx.WithTracer(NewTracer(provider)))
+	return translator, exporter
+}
+
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestTracer(t *testing.T) {
+	translator, exporter := newTestTranslator(t, http.StatusOK)
+
+	result, err := translator.TranslateText([]string{"Hello", " world"}, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, "[DE]Hello[DE] world", result)
+
+	// Spans are exported as they end, children first.
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	call, batch, root := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "deeplx.TranslateText", root.Name)
+	assert.False(t, root.Parent.IsValid())
+	assert.Equal(t, "deeplx.translate", batch.Name)
+	assert.Equal(t, root.SpanContext.SpanID(), batch.Parent.SpanID())
+	assert.Equal(t, "deeplx.http", call.Name)
+	assert.Equal(t, batch.SpanContext.SpanID(), call.Parent.SpanID())
+	assert.Equal(t, root.SpanContext.TraceID(), call.SpanContext.TraceID())
+	assert.Equal(t, ScopeName, root.InstrumentationScope.Name)
+	assert.Equal(t, trace.SpanKindInternal, root.SpanKind)
+	assert.Equal(t, trace.SpanKindInternal, batch.SpanKind)
+	assert.Equal(t, trace.SpanKindClient, call.SpanKind)
+
+	rootAttrs := attrs(root)
+	assert.Equal(t, "v2", rootAttrs[deeplx.TraceAttrVersion].AsString())
+	assert.NotEmpty(t, rootAttrs[deeplx.TraceAttrHost].AsString())
+	assert.Equal(t, "DE", rootAttrs[deeplx.TraceAttrTargetLang].AsString())
+	assert.Equal(t, int64(2), rootAttrs[deeplx.TraceAttrSegments].AsInt64())
+
+	callAttrs := attrs(call)
+	assert.Equal(t, "POST", callAttrs[deeplx.TraceAttrMethod].AsString())
+	assert.Equal(t, "/v2/translate", callAttrs[deeplx.TraceAttrEndpoint].AsString())
+	assert.Equal(t, int64(200), callAttrs[deeplx.TraceAttrStatusCode].AsInt64())
+
+	for _, span := range spans {
+		assert.Equal(t, codes.Unset, span.Status.Code)
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	translator, exporter := newTestTranslator(t, http.StatusTooManyRequests)
+
+	_, err := translator.TranslateText([]string{"Hello"}, "DE")
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	assert.Equal(t, int64(429), attrs(spans[0])[deeplx.TraceAttrStatusCode].AsInt64())
+	for _, span := range spans {
+		assert.Equal(t, codes.Error, span.Status.Code)
+		assert.Equal(t, "429 - Too Many Requests", span.Status.Description)
+		if assert.Len(t, span.Events, 1) {
+			assert.Equal(t, "exception", span.Events[0].Name)
+		}
+	}
+}
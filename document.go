@@ -0,0 +1,56 @@
+package deeplx_translator
+
+import (
+	"strings"
+)
+
+// document is a text document split into literal markup and translatable
+// segments, so that it can be rendered again with the segments replaced.
+type document struct {
+	parts    []documentPart
+	segments []string
+}
+
+type documentPart struct {
+	text    string
+	segment int // index into segments, or -1 for literal text
+}
+
+// literal adds text to be rendered as is.
+func (d *document) literal(text string) {
+	if text != "" {
+		d.parts = append(d.parts, documentPart{text: text, segment: -1})
+	}
+}
+
+// prose adds text as a translatable segment, keeping any surrounding
+// whitespace as literal text.
+func (d *document) prose(text string) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		d.literal(text)
+		return
+	}
+	start := strings.Index(text, trimmed)
+	d.literal(text[:start])
+	d.parts = append(d.parts, documentPart{segment: len(d.segments)})
+	d.segments = append(d.segments, trimmed)
+	d.literal(text[start+len(trimmed):])
+}
+
+// render renders the document with its segments replaced by the supplied
+// ones, passed through escape first if it is not nil.
+func (d *document) render(segments []string, escape func(string) string) string {
+	var sb strings.Builder
+	for _, part := range d.parts {
+		switch {
+		case part.segment < 0:
+			sb.WriteString(part.text)
+		case escape != nil:
+			sb.WriteString(escape(segments[part.segment]))
+		default:
+			sb.WriteString(segments[part.segment])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package deeplx_translator
+
+import (
+	"context"
+	"sync"
+	"unicode/utf8"
+)
+
+// CostEstimate is what a translation would cost, as estimated by DryRun.
+type CostEstimate struct {
+	Requests   int
+	Characters int64
+}
+
+type dryRunKey struct{}
+
+type dryRun struct {
+	mu       sync.Mutex
+	estimate CostEstimate
+}
+
+// DryRun runs TranslateTextContext without sending anything, and returns
+// the number of requests and billed characters it would take. Text goes
+// through the same splitting, batching, deduplication and translation
+// memory lookups as it would for real. Middleware is not run, and budgets
+// are neither checked nor charged.
+func (t *Translator) DryRun(ctx context.Context, text any, targetLang string, opts ...TranslateOption) (*CostEstimate, error) {
+	dr := &dryRun{}
+	ctx = context.WithValue(ctx, dryRunKey{}, dr)
+	if _, err := t.TranslateTextContext(ctx, text, targetLang, opts...); err != nil {
+		return nil, err
+	}
+	return &dr.estimate, nil
+}
+
+func (dr *dryRun) add(characters int64) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.estimate.Requests++
+	dr.estimate.Characters += characters
+}
+
+// response returns a response echoing the untranslated text.
+func (dr *dryRun) response(version Version, text any, targetLang string) any {
+	if version == VersionV1 {
+		v, _ := text.(string)
+		return &TranslationResultV1{Code: 200, Data: v, TargetLang: targetLang}
+	}
+
+	texts, _ := text.([]string)
+	result := &TranslationResultV2{}
+	for _, v := range texts {
+		result.Translations = append(result.Translations, TranslationV2{Text: v})
+	}
+	return result
+}
+
+// textCharacters returns the number of characters of the text of a request,
+// which is what the API bills.
+func textCharacters(text any) int64 {
+	switch v := text.(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v))
+	case []string:
+		var n int64
+		for _, s := range v {
+			n += int64(utf8.RuneCountInString(s))
+		}
+		return n
+	default:
+		return 0
+	}
+}
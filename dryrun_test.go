@@ -0,0 +1,72 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	server := newMockServer(t)
+	budget := NewBudget(WithDailyLimit(1))
+
+	long := strings.Repeat("Sentence. ", 120)
+	for _, test := range []struct {
+		version  string
+		text     any
+		estimate CostEstimate
+	}{
+		{"/v1", "Hello", CostEstimate{1, 5}},
+		{"/v2", []string{"OK", "Cancel", "OK"}, CostEstimate{1, 8}},
+		{"/v2", long, CostEstimate{1, 9 + 10}}, // split at terminators, duplicates sent once
+	} {
+		translator := NewTranslator("", WithBaseURL(server.URL+test.version), WithBudget(budget))
+		estimate, err := translator.DryRun(context.Background(), test.text, "DE")
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.estimate, *estimate)
+		}
+	}
+
+	// Segments served from the translation memory are not billed, and the
+	// rest is sent in batches.
+	tm := NewTranslationMemory()
+	tm.Add("", "de", "0", "null")
+	segments := make([]string, 2*maxBatchSize+2)
+	for i := range segments {
+		segments[i] = strconv.Itoa(i % 100)
+	}
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithTranslationMemory(tm))
+	estimate, err := translator.DryRun(context.Background(), segments, "DE")
+	if assert.NoError(t, err) {
+		assert.Equal(t, CostEstimate{2, 9 + 2*90 + 1}, *estimate)
+	}
+
+	assert.Zero(t, server.requests.Load())
+	assert.Zero(t, budget.Used("", BudgetDaily))
+}
+
+func TestDryRunMiddleware(t *testing.T) {
+	server := newMockServer(t)
+
+	var requests int
+	recorder := func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			requests++
+			return next(ctx, req)
+		}
+	}
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithMiddleware(recorder))
+
+	estimate, err := translator.DryRun(context.Background(), []string{"Hello", "world"}, "DE")
+	if assert.NoError(t, err) {
+		assert.Equal(t, CostEstimate{1, 10}, *estimate)
+	}
+	assert.Zero(t, requests)
+
+	_, err = translator.TranslateText([]string{"Hello", "world"}, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,221 @@
+package deeplx_translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	htmlTagRegexp    = regexp.MustCompile(`^</?[a-zA-Z](?:[^<>"']|"[^"]*"|'[^']*')*>`)
+	htmlEntityRegexp = regexp.MustCompile(`^&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+	// htmlTranslatableAttrs are the attributes whose values are translated.
+	htmlTranslatableAttrs = []string{"alt", "title", "placeholder", "aria-label"}
+
+	// htmlSkippedElements are the elements whose content is never translated.
+	htmlSkippedElements = []string{"script", "style", "code", "pre", "kbd", "samp", "template"}
+
+	// htmlInlineElements are the elements kept within the segment of the
+	// surrounding text, so that sentences are translated as a whole.
+	htmlInlineElements = []string{
+		"a", "abbr", "b", "bdi", "bdo", "br", "cite", "data", "del", "dfn", "em",
+		"font", "i", "img", "ins", "label", "mark", "q", "s", "small", "span",
+		"strong", "sub", "sup", "time", "u", "wbr",
+	}
+
+	// htmlVoidElements are the elements that never have content.
+	htmlVoidElements = []string{
+		"area", "base", "br", "col", "embed", "hr", "img",
+		"input", "link", "meta", "source", "track", "wbr",
+	}
+)
+
+// TranslateHTML translates the text of an HTML document or fragment locally,
+// without relying on tag handling support of the backend, so it works with
+// every API version.
+//
+// The text of each block, such as a paragraph or list item, is sent as a
+// single segment, inline markup such as <b> or <a> and character references
+// protected like placeholders, so that sentences are translated in context.
+// The alt, title, placeholder and aria-label attributes are translated as
+// segments of their own; an inline element with one of them therefore ends
+// the segment of the text around it. The content of script, style, code, pre
+// and similar elements is skipped, as is that of any element marked with
+// translate="no" or the notranslate class. Markup outside of segments is
+// written back out as found; only tags with translated attributes are
+// re-serialized.
+func (t *Translator) TranslateHTML(ctx context.Context, document string, targetLang string, opts ...TranslateOption) (string, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return "", fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	doc, err := parseHTML(strings.NewReader(document))
+	if err != nil {
+		return "", err
+	}
+
+	segments := make([]string, len(doc.segments))
+	tokens := make([][]string, len(doc.segments))
+	for i, segment := range doc.segments {
+		segments[i], tokens[i] = protectTokens(segment, true, htmlInlineLen)
+	}
+
+	po := protectOptions(o, true)
+	po.chunked = true
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(po))
+	if err != nil {
+		return "", err
+	}
+	for i := range results {
+		if results[i], err = restorePlaceholders(results[i], tokens[i], true); err != nil {
+			return "", err
+		}
+	}
+	return doc.render(results, nil), nil
+}
+
+// htmlInlineLen returns the length of the tag or character reference at the
+// start of s, or 0 if s does not start with one.
+func htmlInlineLen(s string) int {
+	if m := htmlTagRegexp.FindString(s); m != "" {
+		return len(m)
+	}
+	return len(htmlEntityRegexp.FindString(s))
+}
+
+// parseHTML splits an HTML document into markup and translatable segments,
+// which are HTML themselves: runs of text and inline markup, and escaped
+// attribute values.
+func parseHTML(r io.Reader) (*document, error) {
+	var (
+		doc = &document{}
+		z   = xhtml.NewTokenizer(r)
+
+		skipTag   string // element that started the skipped region, if any
+		skipDepth int    // nesting level of skipTag within the skipped region
+
+		run     strings.Builder // text and inline markup of the current block
+		runText bool            // whether run has text other than whitespace
+	)
+	flush := func() {
+		if runText {
+			doc.prose(run.String())
+		} else {
+			doc.literal(run.String())
+		}
+		run.Reset()
+		runText = false
+	}
+
+	for {
+		tt := z.Next()
+		raw := string(z.Raw())
+		token := z.Token()
+
+		if skipTag == "" {
+			switch tt {
+			case xhtml.TextToken:
+				run.WriteString(raw)
+				runText = runText || strings.TrimSpace(raw) != ""
+				continue
+			case xhtml.StartTagToken, xhtml.SelfClosingTagToken, xhtml.EndTagToken:
+				if slices.Contains(htmlInlineElements, token.Data) && !isHTMLSkipped(token) &&
+					!slices.ContainsFunc(token.Attr, isHTMLTranslatableAttr) {
+					run.WriteString(raw)
+					continue
+				}
+			}
+			flush()
+		}
+
+		switch tt {
+		case xhtml.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("error parsing HTML: %w", err)
+			}
+			return doc, nil
+		case xhtml.TextToken:
+			doc.literal(raw)
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if skipTag != "" {
+				if token.Data == skipTag && tt == xhtml.StartTagToken {
+					skipDepth++
+				}
+				doc.literal(raw)
+				continue
+			}
+
+			if isHTMLSkipped(token) {
+				if tt == xhtml.StartTagToken && !slices.Contains(htmlVoidElements, token.Data) {
+					skipTag, skipDepth = token.Data, 1
+				}
+				doc.literal(raw)
+				continue
+			}
+
+			if !slices.ContainsFunc(token.Attr, isHTMLTranslatableAttr) {
+				doc.literal(raw)
+				continue
+			}
+			writeHTMLTag(doc, token, tt == xhtml.SelfClosingTagToken)
+		case xhtml.EndTagToken:
+			if skipTag != "" && token.Data == skipTag {
+				if skipDepth--; skipDepth == 0 {
+					skipTag = ""
+				}
+			}
+			doc.literal(raw)
+		default:
+			doc.literal(raw)
+		}
+	}
+}
+
+// writeHTMLTag re-serializes a start tag with its translatable attribute
+// values as segments.
+func writeHTMLTag(doc *document, token xhtml.Token, selfClosing bool) {
+	doc.literal("<" + token.Data)
+	for _, attr := range token.Attr {
+		doc.literal(" " + attr.Key + `="`)
+		if isHTMLTranslatableAttr(attr) {
+			doc.prose(html.EscapeString(attr.Val))
+		} else {
+			doc.literal(html.EscapeString(attr.Val))
+		}
+		doc.literal(`"`)
+	}
+	if selfClosing {
+		doc.literal("/")
+	}
+	doc.literal(">")
+}
+
+func isHTMLTranslatableAttr(attr xhtml.Attribute) bool {
+	return slices.Contains(htmlTranslatableAttrs, attr.Key)
+}
+
+// isHTMLSkipped reports whether the element and its content should be left
+// untranslated.
+func isHTMLSkipped(token xhtml.Token) bool {
+	if slices.Contains(htmlSkippedElements, token.Data) {
+		return true
+	}
+	for _, attr := range token.Attr {
+		switch {
+		case attr.Key == "translate" && strings.EqualFold(attr.Val, "no"):
+			return true
+		case attr.Key == "class" && slices.Contains(strings.Fields(attr.Val), "notranslate"):
+			return true
+		}
+	}
+	return false
+}
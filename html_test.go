@@ -0,0 +1,87 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const htmlSample = `<!DOCTYPE html>
+<html lang="en">
+<head><title>Hello &amp; welcome</title><style>p { color: red; }</style></head>
+<body>
+  <p class=intro>Hello, <b>world</b>!</p>
+  <img src="a.png" alt="A picture">
+  <input type="text" placeholder="Your name" disabled/>
+  <pre><code>fmt.Println("hi")</code></pre>
+  <div translate="no">Brand <div>Name</div> <span title="Keep">here</span></div>
+  <p class="notranslate">Skip me</p>
+  <script>var s = "<p>not html</p>";</script>
+  <!-- a comment -->
+</body>
+</html>
+`
+
+func TestParseHTML(t *testing.T) {
+	doc, err := parseHTML(strings.NewReader(htmlSample))
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{
+			"Hello &amp; welcome",
+			"Hello, <b>world</b>!",
+			"A picture",
+			"Your name",
+		}, doc.segments)
+		// Only tags with translatable attributes are re-serialized.
+		expected := strings.Replace(htmlSample, "disabled/>", `disabled=""/>`, 1)
+		assert.Equal(t, expected, doc.render(doc.segments, nil))
+	}
+}
+
+func TestTranslateHTML(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	result, err := translator.TranslateHTML(context.Background(), htmlSample, "de")
+	if assert.NoError(t, err) {
+		assert.Equal(t, `<!DOCTYPE html>
+<html lang="en">
+<head><title>[DE]Hello &amp; welcome</title><style>p { color: red; }</style></head>
+<body>
+  <p class=intro>[DE]Hello, <b>world</b>!</p>
+  <img src="a.png" alt="[DE]A picture">
+  <input type="text" placeholder="[DE]Your name" disabled=""/>
+  <pre><code>fmt.Println("hi")</code></pre>
+  <div translate="no">Brand <div>Name</div> <span title="Keep">here</span></div>
+  <p class="notranslate">Skip me</p>
+  <script>var s = "<p>not html</p>";</script>
+  <!-- a comment -->
+</body>
+</html>
+`, result)
+	}
+}
+
+func TestTranslateHTMLInline(t *testing.T) {
+	for _, version := range []string{"/v1", "/v2"} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+version))
+
+		result, err := translator.TranslateHTML(context.Background(),
+			`<ul><li>Click <a href="/next">here</a> to continue.</li><li>Run <code>make</code> & <img src="x.png" alt="Tom & Jerry"> now</li></ul>`, "de")
+		if assert.NoError(t, err, version) {
+			assert.Equal(t, `<ul><li>[DE]Click <a href="/next">here</a> to continue.</li>`+
+				`<li>[DE]Run <code>make</code> [DE]& <img src="x.png" alt="[DE]Tom &amp; Jerry"> [DE]now</li></ul>`, result)
+		}
+	}
+
+	// Inline markup and character references are protected, so that no tag
+	// handling support of the backend is needed.
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+	_, err := translator.TranslateHTML(context.Background(), `<p>Hello <b>world</b> &amp; you</p>`, "de")
+	assert.NoError(t, err)
+	assert.Equal(t, []any{`Hello <x-ph id="0">&lt;b&gt;</x-ph>world<x-ph id="1">&lt;/b&gt;</x-ph> <x-ph id="2">&amp;amp;</x-ph> you`},
+		server.lastRequest()["text"])
+}
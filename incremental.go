@@ -0,0 +1,126 @@
+package deeplx_translator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IncrementalState records the translations of a document's segments by the
+// hash of their source, so that a later revision of the document only needs
+// its added or modified segments translated. It can be stored as JSON.
+type IncrementalState struct {
+	TargetLang string            `json:"target_lang"`
+	Options    string            `json:"options"`  // hash of the translate options
+	Segments   map[string]string `json:"segments"` // translations by source hash
+}
+
+type IncrementalTranslationResult struct {
+	Text  string
+	State *IncrementalState
+
+	// Translated and Reused count the paragraphs that were translated anew
+	// and those taken from the previous state, respectively.
+	Translated int
+	Reused     int
+}
+
+// TranslateIncremental translates a plain text document split into
+// paragraphs at blank lines, reusing the translations recorded in previous
+// for paragraphs that are unchanged, wherever they have moved to. Only added
+// or modified paragraphs are sent. Whitespace around paragraphs is kept.
+//
+// The previous state is only used if it was recorded for the same target
+// language and options, and may be nil for the first translation. The
+// returned state covers the current paragraphs only, and is meant to be
+// passed in for the next revision.
+func (t *Translator) TranslateIncremental(ctx context.Context, source string, previous *IncrementalState, targetLang string, opts ...TranslateOption) (*IncrementalTranslationResult, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return nil, fmt.Errorf("error setting translate option: %w", err)
+	}
+	options, err := json.Marshal(o)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding translate options: %w", err)
+	}
+
+	state := &IncrementalState{
+		TargetLang: targetLang,
+		Options:    hashSegment(string(options)),
+		Segments:   make(map[string]string),
+	}
+	if previous != nil && (!strings.EqualFold(previous.TargetLang, targetLang) || previous.Options != state.Options) {
+		previous = nil
+	}
+
+	var (
+		doc      = parseParagraphs(source)
+		result   = &IncrementalTranslationResult{State: state}
+		hashes   = make([]string, len(doc.segments))
+		changed  []string
+		queued   = make(map[string]bool)
+		reusable = func(hash string) (string, bool) {
+			if previous == nil {
+				return "", false
+			}
+			translation, ok := previous.Segments[hash]
+			return translation, ok
+		}
+	)
+	for i, segment := range doc.segments {
+		hashes[i] = hashSegment(segment)
+		if translation, ok := reusable(hashes[i]); ok {
+			state.Segments[hashes[i]] = translation
+			result.Reused++
+			continue
+		}
+		result.Translated++
+		// Identical new paragraphs are only translated once.
+		if !queued[hashes[i]] {
+			queued[hashes[i]] = true
+			changed = append(changed, segment)
+		}
+	}
+
+	translations, err := t.translateSegments(ctx, changed, targetLang, withTranslateOptions(o))
+	if err != nil {
+		return nil, err
+	}
+	for i, segment := range changed {
+		state.Segments[hashSegment(segment)] = translations[i]
+	}
+
+	segments := make([]string, len(doc.segments))
+	for i, hash := range hashes {
+		segments[i] = state.Segments[hash]
+	}
+	result.Text = doc.render(segments, nil)
+	return result, nil
+}
+
+// parseParagraphs splits plain text into paragraphs separated by blank lines.
+func parseParagraphs(text string) *document {
+	var (
+		doc       = &document{}
+		paragraph strings.Builder
+	)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			paragraph.WriteString(line)
+			continue
+		}
+		doc.prose(paragraph.String())
+		paragraph.Reset()
+		doc.literal(line)
+	}
+	doc.prose(paragraph.String())
+	return doc
+}
+
+func hashSegment(segment string) string {
+	sum := sha256.Sum256([]byte(segment))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package deeplx_translator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateIncremental(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	first, err := translator.TranslateIncremental(context.Background(), "One.\n\nTwo\nlines.\n\n  Three.\n", nil, "de")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "[DE]One.\n\n[DE]Two\nlines.\n\n  [DE]Three.\n", first.Text)
+	assert.Equal(t, 3, first.Translated)
+	assert.Equal(t, 0, first.Reused)
+
+	// The state survives a round trip through storage.
+	data, err := json.Marshal(first.State)
+	assert.NoError(t, err)
+	var state IncrementalState
+	assert.NoError(t, json.Unmarshal(data, &state))
+
+	server.requests.Store(0)
+	second, err := translator.TranslateIncremental(context.Background(), "Three.\n\nOne.\n\nFour.\n\n\nFour.", &state, "DE")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[DE]Three.\n\n[DE]One.\n\n[DE]Four.\n\n\n[DE]Four.", second.Text)
+		assert.Equal(t, 2, second.Translated)
+		assert.Equal(t, 2, second.Reused)
+		assert.Len(t, second.State.Segments, 3)
+		assert.Equal(t, []any{"Four."}, server.lastRequest()["text"])
+		assert.Equal(t, int64(1), server.requests.Load())
+	}
+
+	// Changed options invalidate the previous state.
+	third, err := translator.TranslateIncremental(context.Background(), "One.", second.State, "de", WithFormality("more"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, third.Translated)
+		assert.Equal(t, 0, third.Reused)
+	}
+}
@@ -0,0 +1,172 @@
+package deeplx_translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when every key of the key pool has exceeded
+// its quota.
+var ErrQuotaExceeded = errors.New("quota exceeded for all auth keys")
+
+// KeyPool is a set of auth keys used in turn, skipping keys that have
+// exceeded their quota. It is safe for concurrent use, and may be shared by
+// several Translators.
+type KeyPool struct {
+	mu   sync.Mutex
+	keys []*poolKey
+	next int
+
+	resetTime     func(time.Time) time.Time
+	checkInterval time.Duration
+	now           func() time.Time
+}
+
+type poolKey struct {
+	key            string
+	exhaustedUntil time.Time
+	checkedAt      time.Time
+}
+
+// KeyPoolOption is a functional option for configuring the KeyPool.
+type KeyPoolOption func(*KeyPool)
+
+// WithQuotaReset sets the function returning until when a key that has
+// exceeded its quota at the given time is left out. By default, that is
+// the start of the following month in UTC.
+func WithQuotaReset(fn func(exceededAt time.Time) time.Time) KeyPoolOption {
+	return func(p *KeyPool) {
+		p.resetTime = fn
+	}
+}
+
+// WithUsageCheck makes the pool check a key's usage with the /usage endpoint
+// before using it, at most once per interval, so that exhausted keys are
+// skipped without a failed translation request first.
+func WithUsageCheck(interval time.Duration) KeyPoolOption {
+	return func(p *KeyPool) {
+		p.checkInterval = interval
+	}
+}
+
+// NewKeyPool creates a pool of the supplied auth keys.
+func NewKeyPool(keys []string, opts ...KeyPoolOption) *KeyPool {
+	p := &KeyPool{
+		resetTime: func(t time.Time) time.Time {
+			t = t.UTC()
+			return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		},
+		now: time.Now,
+	}
+	for _, key := range keys {
+		p.keys = append(p.keys, &poolKey{key: key})
+	}
+	for _, option := range opts {
+		option(p)
+	}
+	return p
+}
+
+// WithKeyPool makes the Translator rotate through the keys of the pool
+// instead of using a single auth key. A key is left out once a request made
+// with it fails with 456 (quota exceeded), and the request is retried with
+// the next key. Each key is tried at most once per request; if all of them
+// fail, the error wraps ErrQuotaExceeded.
+//
+// Unless the base URL has been overridden with WithBaseURL, each key is sent
+// to the Free or Pro API depending on whether it is a Free key.
+func WithKeyPool(pool *KeyPool) TranslatorOption {
+	return func(t *Translator) {
+		t.keys = pool
+	}
+}
+
+// Available returns the number of keys that have not exceeded their quota.
+func (p *KeyPool) Available() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	now := p.now()
+	for _, k := range p.keys {
+		if !now.Before(k.exhaustedUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+// acquire returns the next available key, and whether it is due for a
+// usage check.
+func (p *KeyPool) acquire() (*poolKey, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for range p.keys {
+		k := p.keys[p.next]
+		p.next = (p.next + 1) % len(p.keys)
+		if now.Before(k.exhaustedUntil) {
+			continue
+		}
+
+		check := p.checkInterval > 0 && now.Sub(k.checkedAt) >= p.checkInterval
+		if check {
+			k.checkedAt = now
+		}
+		return k, check, nil
+	}
+	return nil, false, ErrQuotaExceeded
+}
+
+// exhaust leaves the key out until its quota is reset.
+func (p *KeyPool) exhaust(k *poolKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.exhaustedUntil = p.resetTime(p.now())
+}
+
+// keyBaseURL returns the base URL to use with key, following the type of the
+// key unless the base URL has been overridden.
+func (t *Translator) keyBaseURL(key string) string {
+	if t.baseURL != deeplFreeAPIURLv2 && t.baseURL != deeplProAPIURLv2 {
+		return t.baseURL
+	}
+	if isFreeAccountAuthKey(key) {
+		return deeplFreeAPIURLv2
+	}
+	return deeplProAPIURLv2
+}
+
+// quotaExceeded checks with the /usage endpoint whether the key has used up
+// its character limit.
+func (t *Translator) quotaExceeded(ctx context.Context, key string) (bool, error) {
+	// The usage check is not part of the translation it is made for.
+	ctx = withRequestInfo(ctx, requestInfo{})
+	res, err := t.send(ctx, t.keyBaseURL(key), key, http.MethodGet, "usage", nil, nil)
+	if err != nil {
+		return false, err
+	}
+	//nolint:errcheck
+	defer res.Body.Close()
+	if res.StatusCode == 456 {
+		return true, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("error checking usage: %w", httpError(res.StatusCode))
+	}
+
+	var usage struct {
+		CharacterCount int64 `json:"character_count"`
+		CharacterLimit int64 `json:"character_limit"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&usage); err != nil {
+		return false, fmt.Errorf("error decoding usage: %w", err)
+	}
+	return usage.CharacterLimit > 0 && usage.CharacterCount >= usage.CharacterLimit, nil
+}
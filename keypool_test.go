@@ -0,0 +1,94 @@
+package deeplx_translator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPool(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		keys   []string
+		usages []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "DeepL-Auth-Key ")
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/usage") {
+			usages = append(usages, key)
+			if key == "used-up" {
+				_, _ = w.Write([]byte(`{"character_count":500000,"character_limit":500000}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"character_count":10,"character_limit":500000}`))
+			return
+		}
+
+		keys = append(keys, key)
+		if key == "exceeded" {
+			w.WriteHeader(456)
+			return
+		}
+		_, _ = w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Hallo"}]}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	pool := NewKeyPool([]string{"exceeded", "used-up", "ok"}, WithUsageCheck(time.Hour))
+	pool.now = func() time.Time { return now }
+
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithKeyPool(pool))
+	for range 3 {
+		text, err := translator.TranslateText([]string{"Hello"}, "DE")
+		assert.NoError(t, err)
+		assert.Equal(t, "Hallo", text)
+	}
+	assert.Equal(t, []string{"exceeded", "ok", "ok", "ok"}, keys)
+	assert.Equal(t, []string{"exceeded", "used-up", "ok"}, usages)
+	assert.Equal(t, 1, pool.Available())
+
+	// Exhausted keys come back at the start of the next month.
+	now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 3, pool.Available())
+
+	exhausted := NewKeyPool([]string{"exceeded"})
+	translator = NewTranslator("", WithBaseURL(server.URL+"/v2"), WithKeyPool(exhausted))
+	_, err := translator.TranslateText([]string{"Hello"}, "DE")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+}
+
+func TestKeyBaseURL(t *testing.T) {
+	translator := NewTranslator("", WithKeyPool(NewKeyPool(nil)))
+	assert.Equal(t, deeplFreeAPIURLv2, translator.keyBaseURL("key:fx"))
+	assert.Equal(t, deeplProAPIURLv2, translator.keyBaseURL("key"))
+
+	translator = NewTranslator("", WithBaseURL("https://deeplx.example.com/v2"))
+	assert.Equal(t, "https://deeplx.example.com/v2", translator.keyBaseURL("key:fx"))
+}
+
+func TestKeyPoolImmediateReset(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(456)
+	}))
+	defer server.Close()
+
+	// The quota of an exceeded key is reset right away, so the pool never
+	// runs out of keys.
+	pool := NewKeyPool([]string{"a", "b"}, WithQuotaReset(func(t time.Time) time.Time { return t }))
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithKeyPool(pool))
+	_, err := translator.TranslateText([]string{"Hello"}, "DE")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.ErrorContains(t, err, "456")
+	assert.Equal(t, int64(2), requests.Load())
+}
@@ -0,0 +1,392 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type LocaleFormat uint8
+
+const (
+	LocaleFormatJSON LocaleFormat = iota + 1
+	LocaleFormatYAML
+)
+
+var (
+	jsonIndentRegexp = regexp.MustCompile(`\n([ \t]+)\S`)
+	jsonColonRegexp  = regexp.MustCompile(`"(\s*:\s*)`)
+)
+
+// LocaleFileOptions configures TranslateLocaleFile.
+type LocaleFileOptions struct {
+	Format LocaleFormat
+
+	// Include and Exclude select the string leaves to translate by key path,
+	// e.g. "home.title" or "errors.*.message". Path segments are matched
+	// with path.Match, and "**" matches any number of segments. All leaves
+	// are included if Include is empty, and Exclude takes precedence.
+	// Leaves that are not selected are copied untranslated.
+	Include []string
+	Exclude []string
+
+	// Existing is a previous version of the target file. Leaves that already
+	// have a translation there are kept instead of being translated again.
+	Existing []byte
+
+	// RootKey, if set, replaces the single top-level key of Rails-style
+	// files, e.g. "en:" becomes "de:". Key paths are matched below it.
+	RootKey string
+}
+
+// TranslateLocaleFile translates the string leaves of a nested JSON or YAML
+// locale file, such as those of i18next, vue-i18n or Rails, and returns the
+// file for the target language with the same structure and key order.
+// Interpolation placeholders such as {{name}}, {name} and %{name} are
+// protected from the translation engine.
+func (t *Translator) TranslateLocaleFile(ctx context.Context, source []byte, targetLang string, lo LocaleFileOptions, opts ...TranslateOption) ([]byte, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return nil, fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	root, err := decodeLocaleFile(source, lo.Format)
+	if err != nil {
+		return nil, err
+	}
+	var existing *yaml.Node
+	if len(lo.Existing) > 0 {
+		if existing, err = decodeLocaleFile(lo.Existing, lo.Format); err != nil {
+			return nil, fmt.Errorf("error decoding existing file: %w", err)
+		}
+	}
+
+	// Step into the locale key of Rails-style files.
+	target := cloneYAMLNode(root)
+	body := target
+	if lo.RootKey != "" {
+		if target.Kind != yaml.MappingNode || len(target.Content) != 2 {
+			return nil, errors.New("root key requires a single top-level key")
+		}
+		target.Content[0].Value = lo.RootKey
+		body = target.Content[1]
+		if existing != nil && existing.Kind == yaml.MappingNode && len(existing.Content) == 2 {
+			existing = existing.Content[1]
+		}
+	}
+
+	var (
+		leaves   []*yaml.Node
+		segments []string
+		tokens   [][]string
+	)
+	walkLocaleNode(body, nil, func(keyPath []string, node *yaml.Node) {
+		if !lo.selects(keyPath) {
+			return
+		}
+		if prev := lookupLocaleNode(existing, keyPath); prev != nil && prev.Kind == yaml.ScalarNode && prev.Value != "" {
+			node.Value = prev.Value
+			return
+		}
+		protected, placeholders := protectPlaceholders(node.Value, true)
+		leaves = append(leaves, node)
+		segments = append(segments, protected)
+		tokens = append(tokens, placeholders)
+	})
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(protectOptions(o, true)))
+	if err != nil {
+		return nil, err
+	}
+	for i, leaf := range leaves {
+		if leaf.Value, err = restorePlaceholders(results[i], tokens[i], true); err != nil {
+			return nil, err
+		}
+	}
+
+	return encodeLocaleFile(target, lo.Format, source)
+}
+
+// selects reports whether the leaf at keyPath should be translated.
+func (lo *LocaleFileOptions) selects(keyPath []string) bool {
+	for _, pattern := range lo.Exclude {
+		if matchKeyPath(strings.Split(pattern, "."), keyPath) {
+			return false
+		}
+	}
+	if len(lo.Include) == 0 {
+		return true
+	}
+	for _, pattern := range lo.Include {
+		if matchKeyPath(strings.Split(pattern, "."), keyPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchKeyPath(pattern, keyPath []string) bool {
+	if len(pattern) == 0 {
+		return len(keyPath) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(keyPath); i++ {
+			if matchKeyPath(pattern[1:], keyPath[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(keyPath) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], keyPath[0]); !ok {
+		return false
+	}
+	return matchKeyPath(pattern[1:], keyPath[1:])
+}
+
+// walkLocaleNode calls fn for every string leaf below node.
+func walkLocaleNode(node *yaml.Node, keyPath []string, fn func([]string, *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkLocaleNode(child, keyPath, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkLocaleNode(node.Content[i+1], append(keyPath[:len(keyPath):len(keyPath)], node.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkLocaleNode(child, append(keyPath[:len(keyPath):len(keyPath)], strconv.Itoa(i)), fn)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && strings.TrimSpace(node.Value) != "" {
+			fn(keyPath, node)
+		}
+	}
+}
+
+// lookupLocaleNode returns the node at keyPath below node, if any.
+func lookupLocaleNode(node *yaml.Node, keyPath []string) *yaml.Node {
+	for node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node == nil || len(keyPath) == 0 {
+		return node
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		if value := yamlMappingValue(node, keyPath[0]); value != nil {
+			return lookupLocaleNode(value, keyPath[1:])
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(keyPath[0]); err == nil && i >= 0 && i < len(node.Content) {
+			return lookupLocaleNode(node.Content[i], keyPath[1:])
+		}
+	}
+	return nil
+}
+
+// yamlMappingValue returns the value of key in a mapping node, if any.
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func cloneYAMLNode(node *yaml.Node) *yaml.Node {
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		clone.Content[i] = cloneYAMLNode(child)
+	}
+	return &clone
+}
+
+// decodeLocaleFile decodes a locale file into the root node of its content.
+func decodeLocaleFile(data []byte, format LocaleFormat) (*yaml.Node, error) {
+	switch format {
+	case LocaleFormatJSON:
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		return decodeJSONNode(d)
+	case LocaleFormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("error decoding YAML: %w", err)
+		}
+		if len(doc.Content) == 0 {
+			return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+		}
+		return doc.Content[0], nil
+	default:
+		return nil, fmt.Errorf("invalid locale format: %d", format)
+	}
+}
+
+// encodeLocaleFile encodes the root node, following the indentation and
+// trailing newline of the source file where possible.
+func encodeLocaleFile(root *yaml.Node, format LocaleFormat, source []byte) ([]byte, error) {
+	switch format {
+	case LocaleFormatJSON:
+		return encodeJSONFile(root, source)
+	case LocaleFormatYAML:
+		buf := &bytes.Buffer{}
+		e := yaml.NewEncoder(buf)
+		e.SetIndent(2)
+		if err := e.Encode(root); err != nil {
+			return nil, fmt.Errorf("error encoding YAML: %w", err)
+		}
+		if err := e.Close(); err != nil {
+			return nil, fmt.Errorf("error encoding YAML: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("invalid locale format: %d", format)
+	}
+}
+
+// decodeJSONNode decodes the next JSON value into a node, keeping the order
+// of object keys.
+func decodeJSONNode(d *json.Decoder) (*yaml.Node, error) {
+	token, err := d.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if token == '[' {
+			node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+		}
+		for d.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := d.Token()
+				if err != nil {
+					return nil, fmt.Errorf("error decoding JSON: %w", err)
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			child, err := decodeJSONNode(d)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		if _, err := d.Token(); err != nil { // closing delimiter
+			return nil, fmt.Errorf("error decoding JSON: %w", err)
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: token}, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: token.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(token)}, nil
+	default: // null
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
+
+// encodeJSONFile encodes the root node as JSON in the style of the source
+// file, i.e. with the same indentation, spacing around colons and trailing
+// newline.
+func encodeJSONFile(root *yaml.Node, source []byte) ([]byte, error) {
+	style := jsonStyle{indent: "  ", colon: ": "}
+	if m := jsonIndentRegexp.FindSubmatch(source); m != nil {
+		style.indent = string(m[1])
+	}
+	if m := jsonColonRegexp.FindSubmatch(source); m != nil && !bytes.ContainsAny(m[1], "\r\n") {
+		style.colon = string(m[1])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := encodeJSONNode(buf, root, style, 0); err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(source, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+type jsonStyle struct {
+	indent string
+	colon  string
+}
+
+func encodeJSONNode(buf *bytes.Buffer, node *yaml.Node, style jsonStyle, depth int) error {
+	newline := func(depth int) {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(style.indent, depth))
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, closing, step := byte('{'), byte('}'), 2
+		if node.Kind == yaml.SequenceNode {
+			open, closing, step = '[', ']', 1
+		}
+		buf.WriteByte(open)
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if step == 2 {
+				if err := encodeJSONString(buf, node.Content[i].Value); err != nil {
+					return err
+				}
+				buf.WriteString(style.colon)
+			}
+			if err := encodeJSONNode(buf, node.Content[i+step-1], style, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(node.Content) > 0 {
+			newline(depth)
+		}
+		buf.WriteByte(closing)
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			buf.WriteString(node.Value)
+			return nil
+		}
+		return encodeJSONString(buf, node.Value)
+	default:
+		return fmt.Errorf("unsupported node kind: %d", node.Kind)
+	}
+	return nil
+}
+
+// encodeJSONString writes s as a JSON string, leaving HTML characters as is.
+func encodeJSONString(buf *bytes.Buffer, s string) error {
+	e := json.NewEncoder(buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(s); err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+	buf.Truncate(buf.Len() - 1) // trailing newline
+	return nil
+}
@@ -0,0 +1,96 @@
+package deeplx_translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchKeyPath(t *testing.T) {
+	tests := []struct {
+		pattern []string
+		keyPath []string
+		match   bool
+	}{
+		{[]string{"home", "title"}, []string{"home", "title"}, true},
+		{[]string{"home", "*"}, []string{"home", "title"}, true},
+		{[]string{"home", "*"}, []string{"home", "nav", "title"}, false},
+		{[]string{"home", "**"}, []string{"home", "nav", "title"}, true},
+		{[]string{"**", "title"}, []string{"title"}, true},
+		{[]string{"**", "title"}, []string{"home", "nav", "title"}, true},
+		{[]string{"errors", "*", "code"}, []string{"errors", "auth", "message"}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchKeyPath(tt.pattern, tt.keyPath), "%v %v", tt.pattern, tt.keyPath)
+	}
+}
+
+func TestTranslateLocaleFileJSON(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := []byte(`{
+    "zebra": "Last <b>key</b> first",
+    "home": {
+        "title": "Welcome, {{name}}!",
+        "count": 3,
+        "items": ["One", "Two"]
+    },
+    "meta": {"id": "do-not-translate"},
+    "empty": {}
+}
+`)
+	existing := []byte(`{"home": {"title": "Willkommen, {{name}}!"}}`)
+
+	result, err := translator.TranslateLocaleFile(context.Background(), source, "de", LocaleFileOptions{
+		Format:   LocaleFormatJSON,
+		Exclude:  []string{"meta.**"},
+		Existing: existing,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{
+    "zebra": "[DE]Last <b>key</b> first",
+    "home": {
+        "title": "Willkommen, {{name}}!",
+        "count": 3,
+        "items": [
+            "[DE]One",
+            "[DE]Two"
+        ]
+    },
+    "meta": {
+        "id": "do-not-translate"
+    },
+    "empty": {}
+}
+`, string(result))
+	}
+}
+
+func TestTranslateLocaleFileYAML(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"))
+
+	source := []byte(`en:
+  # Greeting shown on the home page.
+  greeting: "Hello %{name}"
+  errors:
+    not_found: Not found
+    code: E404
+`)
+	result, err := translator.TranslateLocaleFile(context.Background(), source, "de", LocaleFileOptions{
+		Format:  LocaleFormatYAML,
+		Include: []string{"greeting", "errors.not_*"},
+		RootKey: "de",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `de:
+  # Greeting shown on the home page.
+  greeting: "[DE]Hello %{name}"
+  errors:
+    not_found: '[DE]Not found'
+    code: E404
+`, string(result))
+	}
+}
@@ -0,0 +1,103 @@
+package deeplx_translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LevelTrace is the level below debug at which the text of requests is
+// logged, truncated to logTextLimit characters.
+const LevelTrace = slog.LevelDebug - 4
+
+const logTextLimit = 80
+
+// WithLogger makes the Translator log every API call at debug level. Auth
+// keys are never logged. The text of requests is only logged at LevelTrace.
+func WithLogger(logger *slog.Logger) TranslatorOption {
+	return func(t *Translator) {
+		t.logger = logger
+	}
+}
+
+type logAttrsKey struct{}
+
+// withLogAttrs returns a context carrying attributes to be added to the log
+// records of API calls made with it.
+func withLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, logAttrsKey{}, append(parent[:len(parent):len(parent)], attrs...))
+}
+
+// logAPICall logs a single HTTP request to the API.
+func (t *Translator) logAPICall(ctx context.Context, req *http.Request, res *http.Response, err error, latency time.Duration) {
+	if t.logger == nil || !t.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+		slog.String("version", fmt.Sprintf("v%d", t.version)),
+		slog.Duration("latency", latency),
+	}
+	if res != nil {
+		attrs = append(attrs, slog.Int("status", res.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactError(err)))
+	}
+	parent, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	attrs = append(attrs, parent...)
+
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "deeplx API call", attrs...)
+}
+
+// logText logs the text of a request at LevelTrace.
+func (t *Translator) logText(ctx context.Context, text any, targetLang string) {
+	if t.logger == nil || !t.logger.Enabled(ctx, LevelTrace) {
+		return
+	}
+
+	var value slog.Value
+	switch v := text.(type) {
+	case string:
+		value = slog.StringValue(truncateText(v, logTextLimit))
+	case []string:
+		truncated := make([]string, len(v))
+		for i, s := range v {
+			truncated[i] = truncateText(s, logTextLimit)
+		}
+		value = slog.AnyValue(truncated)
+	}
+	t.logger.LogAttrs(ctx, LevelTrace, "deeplx request text",
+		slog.String("target_lang", targetLang), slog.Attr{Key: "text", Value: value})
+}
+
+// redactError returns the message of err without the query of any URL,
+// where DeepLX setups commonly pass their token.
+func redactError(err error) string {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err.Error()
+	}
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && u.RawQuery != "" {
+		u.RawQuery = "REDACTED"
+		return strings.ReplaceAll(err.Error(), urlErr.URL, u.String())
+	}
+	return err.Error()
+}
+
+func truncateText(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,97 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if assert.NoError(t, json.Unmarshal([]byte(line), &record)) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestLogging(t *testing.T) {
+	server := newMockServer(t)
+
+	const authKey = "0123456789abcdef-secret:fx"
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: LevelTrace}))
+	translator := NewTranslator(authKey, WithBaseURL(server.URL+"/v2"), WithLogger(logger))
+
+	long := strings.Repeat("x", 2*logTextLimit)
+	_, err := translator.TranslateText([]string{"Hello", long}, "DE")
+	assert.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), long)
+
+	records := decodeLogRecords(t, &buf)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "deeplx request text", records[0]["msg"])
+		assert.Equal(t, "DEBUG-4", records[0]["level"])
+		assert.Equal(t, []any{"Hello", strings.Repeat("x", logTextLimit) + "…"}, records[0]["text"])
+
+		call := records[1]
+		assert.Equal(t, "deeplx API call", call["msg"])
+		assert.Equal(t, "DEBUG", call["level"])
+		assert.Equal(t, "/v2/translate", call["path"])
+		assert.Equal(t, "v2", call["version"])
+		assert.Equal(t, float64(200), call["status"])
+		assert.Equal(t, float64(5+2*logTextLimit), call["characters"])
+		assert.Equal(t, float64(1), call["attempt"])
+		assert.Contains(t, call, "latency")
+	}
+
+	// Batch indices are logged for batched translations, and text is not
+	// logged at debug level.
+	buf.Reset()
+	logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	translator = NewTranslator(authKey, WithBaseURL(server.URL+"/v2"), WithLogger(logger))
+	segments := make([]string, maxBatchSize+1)
+	for i := range segments {
+		segments[i] = strings.Repeat("y", i+1)
+	}
+	_, err = translator.translateSegments(context.Background(), segments, "DE")
+	assert.NoError(t, err)
+
+	records = decodeLogRecords(t, &buf)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, float64(0), records[0]["batch"])
+		assert.Equal(t, float64(1), records[1]["batch"])
+	}
+}
+
+func TestLoggingKeyPool(t *testing.T) {
+	server := newMockServer(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	pool := NewKeyPool([]string{"0123456789abcdef-first", "0123456789abcdef-second"})
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithKeyPool(pool), WithLogger(logger))
+	_, err := translator.TranslateText("Hello", "DE")
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, buf.String())
+	assert.NotContains(t, buf.String(), "first")
+	assert.NotContains(t, buf.String(), "cdef")
+}
+
+func TestRedaction(t *testing.T) {
+	err := &url.Error{Op: "Post", URL: "https://deeplx.example.com/translate?token=secret", Err: errors.New("connection refused")}
+	assert.Equal(t, `Post "https://deeplx.example.com/translate?REDACTED": connection refused`, redactError(err))
+}
@@ -0,0 +1,264 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdFenceRegexp       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdHeadingRegexp     = regexp.MustCompile(`^( {0,3}#{1,6}[ \t]+)(.*?)([ \t]+#+)?([ \t]*)$`)
+	mdSetextRegexp      = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdBreakRegexp       = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	mdHTMLRegexp        = regexp.MustCompile(`^ {0,3}<[a-zA-Z!/?]`)
+	mdLinkDefRegexp     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	mdTableDelimRegexp  = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdBlockquoteRegexp  = regexp.MustCompile(`^( {0,3}>[ \t]?)+`)
+	mdListItemRegexp    = regexp.MustCompile(`^([ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+(?:\[[ xX]\][ \t]+)?)(.*)$`)
+	mdAutolinkRegexp    = regexp.MustCompile(`^<[a-zA-Z][a-zA-Z0-9+.-]*:[^<>\s]*>`)
+	mdInlineHTMLRegexp  = regexp.MustCompile(`^</?[a-zA-Z][^<>]*>`)
+	mdBareURLRegexp     = regexp.MustCompile(`^https?://[^\s<>]*[^\s<>.,;:!?'")\]]`)
+	mdFootnoteRefRegexp = regexp.MustCompile(`^\[\^[^\]\s]+\]`)
+)
+
+// TranslateMarkdown translates the prose of a Markdown document, leaving its
+// syntax untouched.
+//
+// Headings, paragraphs, list items, blockquotes, table cells, link text and
+// image alt text are translated. YAML front matter, fenced and indented code
+// blocks, HTML blocks, link reference definitions, inline code, URLs and
+// inline HTML are kept as is. Soft line breaks within a paragraph are joined
+// with a space, so the translated paragraph is rendered on a single line.
+//
+// Prose is sent in batches with v2, or in chunks of consecutive blocks with
+// v1.
+func (t *Translator) TranslateMarkdown(ctx context.Context, markdown string, targetLang string, opts ...TranslateOption) (string, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return "", fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	doc := parseMarkdown(markdown)
+
+	segments := make([]string, len(doc.segments))
+	tokens := make([][]string, len(doc.segments))
+	for i, segment := range doc.segments {
+		segments[i], tokens[i] = protectTokens(segment, true, markdownInlineLen)
+	}
+
+	po := protectOptions(o, true)
+	po.chunked = true
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(po))
+	if err != nil {
+		return "", err
+	}
+	for i := range results {
+		if results[i], err = restorePlaceholders(results[i], tokens[i], true); err != nil {
+			return "", err
+		}
+	}
+
+	return doc.render(results, nil), nil
+}
+
+// parseMarkdown splits a Markdown document into markup and prose.
+func parseMarkdown(markdown string) *document {
+	var (
+		doc   = &document{}
+		lines = strings.SplitAfter(markdown, "\n")
+	)
+
+	// YAML front matter.
+	if len(lines) > 1 && strings.TrimRight(lines[0], " \t\r\n") == "---" {
+		for i := 1; i < len(lines); i++ {
+			if line := strings.TrimRight(lines[i], " \t\r\n"); line == "---" || line == "..." {
+				doc.literal(strings.Join(lines[:i+1], ""))
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	var (
+		fence     string // opening code fence, if within a fenced code block
+		inHTML    bool
+		inTable   bool
+		inList    bool
+		blank     = true // whether the previous line was blank
+		paragraph []string
+		prefix    string // markup preceding the open paragraph
+		eol       string // line ending of the open paragraph
+	)
+	flush := func() {
+		if len(paragraph) > 0 {
+			doc.literal(prefix)
+			doc.prose(strings.Join(paragraph, " "))
+			doc.literal(eol)
+		}
+		paragraph, prefix, eol = nil, "", ""
+	}
+
+	for i, raw := range lines {
+		line := strings.TrimRight(raw, "\r\n")
+		ending := raw[len(line):]
+
+		switch {
+		case fence != "":
+			if m := mdFenceRegexp.FindStringSubmatch(line); m != nil &&
+				m[1][0] == fence[0] && len(m[1]) >= len(fence) &&
+				strings.TrimSpace(line[len(m[0]):]) == "" {
+				fence = ""
+			}
+			doc.literal(raw)
+			continue
+		case strings.TrimSpace(line) == "":
+			flush()
+			doc.literal(raw)
+			inHTML, inTable, blank = false, false, true
+			continue
+		case inHTML:
+			doc.literal(raw)
+			continue
+		}
+
+		wasBlank := blank
+		blank = false
+
+		switch m := mdFenceRegexp.FindStringSubmatch(line); {
+		case m != nil:
+			flush()
+			fence = m[1]
+			doc.literal(raw)
+		case wasBlank && !inList && len(paragraph) == 0 &&
+			(strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			// Indented code block.
+			doc.literal(raw)
+		case len(paragraph) > 0 && !inList && mdSetextRegexp.MatchString(line):
+			flush()
+			doc.literal(raw)
+		case mdBreakRegexp.MatchString(line):
+			flush()
+			inList = false
+			doc.literal(raw)
+		case mdHTMLRegexp.MatchString(line) && len(paragraph) == 0:
+			inHTML = true
+			doc.literal(raw)
+		case mdLinkDefRegexp.MatchString(line) && len(paragraph) == 0:
+			doc.literal(raw)
+		case mdTableDelimRegexp.MatchString(line) && strings.Contains(line, "|"):
+			flush()
+			inTable = true
+			doc.literal(raw)
+		case inTable || strings.Contains(line, "|") && i+1 < len(lines) &&
+			strings.Contains(lines[i+1], "|") &&
+			mdTableDelimRegexp.MatchString(strings.TrimRight(lines[i+1], "\r\n")):
+			flush()
+			parseMarkdownTableRow(doc, line)
+			doc.literal(ending)
+		default:
+			quote := mdBlockquoteRegexp.FindString(line)
+			rest := line[len(quote):]
+
+			if m := mdHeadingRegexp.FindStringSubmatch(rest); m != nil {
+				flush()
+				doc.literal(quote + m[1])
+				doc.prose(m[2])
+				doc.literal(m[3] + m[4] + ending)
+				continue
+			}
+			if m := mdListItemRegexp.FindStringSubmatch(rest); m != nil {
+				flush()
+				inList = true
+				prefix, rest = quote+m[1], m[2]
+			} else if len(paragraph) == 0 {
+				if wasBlank && !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "\t") {
+					inList = false
+				}
+				prefix, rest = quote+leadingSpace(rest), strings.TrimLeft(rest, " \t")
+			}
+			paragraph = append(paragraph, strings.TrimSpace(rest))
+			eol = ending
+
+			// A hard line break ends the segment.
+			if strings.HasSuffix(line, "  ") || strings.HasSuffix(line, "\\") {
+				trailing := line[len(strings.TrimRight(line, " \\")):]
+				paragraph[len(paragraph)-1] = strings.TrimSuffix(paragraph[len(paragraph)-1], strings.TrimSpace(trailing))
+				eol = trailing + ending
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return doc
+}
+
+// parseMarkdownTableRow adds each cell of a table row as a prose segment.
+func parseMarkdownTableRow(doc *document, line string) {
+	var (
+		start    int
+		escaped  bool
+		backtick bool
+	)
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '`':
+			backtick = !backtick
+		case r == '|' && !backtick:
+			doc.prose(line[start:i])
+			doc.literal("|")
+			start = i + 1
+		}
+	}
+	doc.prose(line[start:])
+}
+
+// markdownInlineLen returns the length of the inline markup at the start of
+// s that must be kept as is, or 0 if there is none.
+func markdownInlineLen(s string) int {
+	switch s[0] {
+	case '`':
+		// Code span, closed by a backtick string of the same length.
+		n := len(s) - len(strings.TrimLeft(s, "`"))
+		if end := strings.Index(s[n:], s[:n]); end >= 0 {
+			return n + end + n
+		}
+	case ']':
+		// Link destination or reference label, e.g. ](url "title") or ][ref].
+		if len(s) > 1 && (s[1] == '(' || s[1] == '[') {
+			closing := map[byte]byte{'(': ')', '[': ']'}[s[1]]
+			depth := 0
+			for i := 1; i < len(s); i++ {
+				switch s[i] {
+				case s[1]:
+					depth++
+				case closing:
+					depth--
+					if depth == 0 {
+						return i + 1
+					}
+				}
+			}
+		}
+	case '[':
+		return len(mdFootnoteRefRegexp.FindString(s))
+	case '<':
+		if n := len(mdAutolinkRegexp.FindString(s)); n > 0 {
+			return n
+		}
+		return len(mdInlineHTMLRegexp.FindString(s))
+	case 'h':
+		return len(mdBareURLRegexp.FindString(s))
+	}
+	return 0
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
@@ -0,0 +1,125 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const markdownSample = "---\n" +
+	"title: Hello\n" +
+	"---\n" +
+	"# Getting started\n" +
+	"\n" +
+	"Install the package with `go get` and read the\n" +
+	"[docs](https://example.com/docs \"Docs\") or https://example.com.\n" +
+	"\n" +
+	"```go\n" +
+	"fmt.Println(\"Hello\")\n" +
+	"```\n" +
+	"\n" +
+	"- First & foremost\n" +
+	"- [x] Second <br> item\n" +
+	"\n" +
+	"> Quoted ![alt text](image.png)\n" +
+	"\n" +
+	"| Name | Value |\n" +
+	"|------|:-----:|\n" +
+	"| `a|b` | Some text |\n" +
+	"\n" +
+	"    indented code\n" +
+	"\n" +
+	"[docs]: https://example.com\n"
+
+func TestParseMarkdown(t *testing.T) {
+	doc := parseMarkdown(markdownSample)
+	assert.Equal(t, []string{
+		"Getting started",
+		"Install the package with `go get` and read the [docs](https://example.com/docs \"Docs\") or https://example.com.",
+		"First & foremost",
+		"Second <br> item",
+		"Quoted ![alt text](image.png)",
+		"Name",
+		"Value",
+		"`a|b`",
+		"Some text",
+	}, doc.segments)
+}
+
+func TestTranslateMarkdown(t *testing.T) {
+	for _, version := range []string{"/v1", "/v2"} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+version))
+
+		result, err := translator.TranslateMarkdown(context.Background(), markdownSample, "de")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "---\n"+
+				"title: Hello\n"+
+				"---\n"+
+				"# [DE]Getting started\n"+
+				"\n"+
+				"[DE]Install the package with `go get` and read the [docs](https://example.com/docs \"Docs\") or https://example.com.\n"+
+				"\n"+
+				"```go\n"+
+				"fmt.Println(\"Hello\")\n"+
+				"```\n"+
+				"\n"+
+				"- [DE]First & foremost\n"+
+				"- [x] [DE]Second <br> item\n"+
+				"\n"+
+				"> [DE]Quoted ![alt text](image.png)\n"+
+				"\n"+
+				"| [DE]Name | [DE]Value |\n"+
+				"|------|:-----:|\n"+
+				"| [DE]`a|b` | [DE]Some text |\n"+
+				"\n"+
+				"    indented code\n"+
+				"\n"+
+				"[docs]: https://example.com\n", result)
+		}
+		if version == "/v1" {
+			assert.Equal(t, int64(1), server.requests.Load())
+		}
+	}
+}
+
+func TestTranslateMarkdownChunks(t *testing.T) {
+	paragraphs := make([]string, 3*maxChunkSize/100)
+	for i := range paragraphs {
+		paragraphs[i] = strconv.Itoa(i) + strings.Repeat(" word", 19)
+	}
+
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"))
+	result, err := translator.TranslateMarkdown(context.Background(), strings.Join(paragraphs, "\n\n"), "de")
+	if assert.NoError(t, err) {
+		for i, paragraph := range strings.Split(result, "\n\n") {
+			assert.Equal(t, "[DE]"+paragraphs[i], paragraph)
+		}
+	}
+	assert.Equal(t, int64(3), server.requests.Load())
+
+	assert.Equal(t, [][]int{{0, 1}, {3}, {4}}, chunkSegments([]string{"ab", "c", "", "def", "g"}, []int{0, 1, 3, 4}, 3))
+}
+
+func TestTranslateMarkdownChunkFallback(t *testing.T) {
+	// An engine dropping the chunk tags.
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"), WithMiddleware(func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			resp, err := next(ctx, req)
+			if result, ok := resp.Result.(*TranslationResultV1); ok {
+				result.Data = chunkSegmentRegexp.ReplaceAllString(result.Data, "$1 ")
+			}
+			return resp, err
+		}
+	}))
+
+	result, err := translator.TranslateMarkdown(context.Background(), "# Title\n\nText\n", "de")
+	assert.NoError(t, err)
+	assert.Equal(t, "# [DE]Title\n\n[DE]Text\n", result)
+	assert.Equal(t, int64(3), server.requests.Load())
+}
@@ -0,0 +1,408 @@
+package deeplx_translator
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// tmxIgnoreElements are the TMX inline elements holding native code, whose
+// content is not part of the segment text.
+var tmxIgnoreElements = []string{"bpt", "ept", "ph", "it", "ut"}
+
+// TranslationMemory stores approved translations of segments per language
+// pair, so they can be reused instead of being translated again. It is safe
+// for concurrent use.
+//
+// Source languages are compared by their base code, e.g. "EN-GB" matches
+// "EN". Target languages are compared exactly, except that a target without
+// a variant also matches entries of any variant, e.g. "DE" matches "de-DE".
+type TranslationMemory struct {
+	mu      sync.RWMutex
+	entries []*tmEntry
+	index   map[string][]*tmEntry // entries by source text
+
+	minSimilarity float64
+}
+
+type tmEntry struct {
+	sourceLang, targetLang string
+	source, target         string
+}
+
+// TranslationMemoryMatch is the result of a translation memory lookup.
+type TranslationMemoryMatch struct {
+	SourceLang string
+	TargetLang string
+	Source     string
+	Target     string
+
+	// Similarity is 1 for an exact match, and the share of characters the
+	// source has in common with the looked up text for a fuzzy match.
+	Similarity float64
+}
+
+// TranslationMemoryOption is a functional option for configuring the
+// TranslationMemory.
+type TranslationMemoryOption func(*TranslationMemory)
+
+// WithFuzzyMatching enables fuzzy matching of segments whose similarity is
+// at least minSimilarity, between 0 and 1. The similarity is based on the
+// edit distance, e.g. 0.9 allows one edit for every ten characters. Only
+// exact matches are used by default.
+//
+// Fuzzy matches are only returned by Lookup, e.g. for suggestions to a
+// reviewer. Translations only ever reuse exact matches, since a fuzzy match
+// may differ in meaning, e.g. "Delete 5 files" and "Delete 6 files".
+//
+// Values outside of 0 to 1 are clamped, both ends leaving fuzzy matching
+// disabled.
+func WithFuzzyMatching(minSimilarity float64) TranslationMemoryOption {
+	return func(tm *TranslationMemory) {
+		tm.minSimilarity = min(max(minSimilarity, 0), 1)
+	}
+}
+
+// NewTranslationMemory creates a new, empty translation memory.
+func NewTranslationMemory(opts ...TranslationMemoryOption) *TranslationMemory {
+	tm := &TranslationMemory{index: make(map[string][]*tmEntry)}
+	for _, option := range opts {
+		option(tm)
+	}
+	return tm
+}
+
+// WithTranslationMemory makes the Translator serve segments found in the
+// translation memory from there, and only send the others to the API. This
+// applies to TranslateText and to all document translations, which look up
+// each of their segments as sent, i.e. after placeholders are protected.
+func WithTranslationMemory(tm *TranslationMemory) TranslatorOption {
+	return func(t *Translator) {
+		t.memory = tm
+	}
+}
+
+// Len returns the number of entries in the translation memory.
+func (tm *TranslationMemory) Len() int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return len(tm.entries)
+}
+
+// Add stores the translation of source, replacing any earlier translation
+// for the same language pair.
+func (tm *TranslationMemory) Add(sourceLang, targetLang, source, target string) {
+	sourceLang, targetLang = normalizeTMSourceLang(sourceLang), strings.ToUpper(targetLang)
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	for _, entry := range tm.index[source] {
+		if entry.sourceLang == sourceLang && entry.targetLang == targetLang {
+			entry.target = target
+			return
+		}
+	}
+	entry := &tmEntry{sourceLang: sourceLang, targetLang: targetLang, source: source, target: target}
+	tm.entries = append(tm.entries, entry)
+	tm.index[source] = append(tm.index[source], entry)
+}
+
+// Lookup returns the best match for text, an exact match if there is one.
+// An empty sourceLang matches entries of any source language.
+func (tm *TranslationMemory) Lookup(sourceLang, targetLang, text string) (TranslationMemoryMatch, bool) {
+	sourceLang, targetLang = normalizeTMSourceLang(sourceLang), strings.ToUpper(targetLang)
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	best, bestScore := tm.lookupExact(sourceLang, targetLang, text), 1.0
+	if best == nil && tm.minSimilarity > 0 && tm.minSimilarity < 1 {
+		for _, entry := range tm.entries {
+			if !entry.matches(sourceLang, targetLang) {
+				continue
+			}
+			score := similarity(text, entry.source, tm.minSimilarity)
+			if score < tm.minSimilarity {
+				continue
+			}
+			if best == nil || score > bestScore || score == bestScore && entry.preferred(best, targetLang) {
+				best, bestScore = entry, score
+			}
+		}
+	}
+
+	if best == nil {
+		return TranslationMemoryMatch{}, false
+	}
+	return TranslationMemoryMatch{
+		SourceLang: best.sourceLang,
+		TargetLang: best.targetLang,
+		Source:     best.source,
+		Target:     best.target,
+		Similarity: bestScore,
+	}, true
+}
+
+// lookupExact returns the entry for text, or nil if there is none. The
+// languages must be normalized, and tm must be locked for reading.
+func (tm *TranslationMemory) lookupExact(sourceLang, targetLang, text string) *tmEntry {
+	var best *tmEntry
+	for _, entry := range tm.index[text] {
+		if entry.matches(sourceLang, targetLang) && (best == nil || entry.preferred(best, targetLang)) {
+			best = entry
+		}
+	}
+	return best
+}
+
+// preferred reports whether e is preferred over an equally good match
+// other, i.e. whether only e is of the requested target variant.
+func (e *tmEntry) preferred(other *tmEntry, targetLang string) bool {
+	return e.targetLang == targetLang && other.targetLang != targetLang
+}
+
+func (e *tmEntry) matches(sourceLang, targetLang string) bool {
+	if sourceLang != "" && e.sourceLang != sourceLang {
+		return false
+	}
+	if e.targetLang == targetLang {
+		return true
+	}
+	base, _, _ := strings.Cut(e.targetLang, "-")
+	return !strings.Contains(targetLang, "-") && base == targetLang
+}
+
+// fill fills in the results of the pending segments found in the memory and
+// returns the segments still pending. Only exact matches are used. Every
+// lookup is reported to metrics, if not nil.
+func (tm *TranslationMemory) fill(segments []string, pending []int, results []string, sourceLang, targetLang string, metrics MetricsCollector) []int {
+	sourceLang, targetLang = normalizeTMSourceLang(sourceLang), strings.ToUpper(targetLang)
+
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	var misses []int
+	for _, i := range pending {
+		entry := tm.lookupExact(sourceLang, targetLang, segments[i])
+		if metrics != nil {
+			metrics.ObserveCache("memory", entry != nil)
+		}
+		if entry != nil {
+			results[i] = entry.target
+			continue
+		}
+		misses = append(misses, i)
+	}
+	return misses
+}
+
+// ImportTMX adds the translation units of a TMX document to the memory. Each
+// variant other than the one in the source language is added as a
+// translation. Inline native code is left out of the segment text.
+func (tm *TranslationMemory) ImportTMX(r io.Reader) error {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("error decoding TMX: %w", err)
+	}
+
+	for _, tu := range doc.Body.Units {
+		srcLang := tu.SourceLang
+		if srcLang == "" {
+			srcLang = doc.Header.SourceLang
+		}
+		if len(tu.Variants) == 0 {
+			continue
+		}
+
+		// With "*all*", any variant may be the source; use the first one.
+		source := &tu.Variants[0]
+		if srcLang != "*all*" {
+			source = nil
+			for i := range tu.Variants {
+				if normalizeTMSourceLang(tu.Variants[i].lang()) == normalizeTMSourceLang(srcLang) {
+					source = &tu.Variants[i]
+					break
+				}
+			}
+			if source == nil {
+				continue
+			}
+		}
+
+		for i := range tu.Variants {
+			if variant := &tu.Variants[i]; variant != source {
+				tm.Add(source.lang(), variant.lang(), source.Seg.Text, variant.Seg.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// ExportTMX writes the contents of the memory as a TMX 1.4 document, with
+// one translation unit per entry.
+func (tm *TranslationMemory) ExportTMX(w io.Writer) error {
+	tm.mu.RLock()
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "deeplx-translator",
+			CreationToolVersion: "1.0",
+			SegType:             "sentence",
+			OTMF:                "deeplx-translator",
+			AdminLang:           "en",
+			SourceLang:          "*all*",
+			DataType:            "plaintext",
+		},
+	}
+	for i, entry := range tm.entries {
+		if i == 0 {
+			doc.Header.SourceLang = entry.sourceLang
+		} else if doc.Header.SourceLang != entry.sourceLang {
+			doc.Header.SourceLang = "*all*"
+		}
+		doc.Body.Units = append(doc.Body.Units, tmxUnit{
+			Variants: []tmxVariant{
+				{Lang: entry.sourceLang, Seg: tmxSeg{Text: entry.source}},
+				{Lang: entry.targetLang, Seg: tmxSeg{Text: entry.target}},
+			},
+		})
+	}
+	tm.mu.RUnlock()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(doc); err != nil {
+		return fmt.Errorf("error encoding TMX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Body    struct {
+		Units []tmxUnit `xml:"tu"`
+	} `xml:"body"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SourceLang          string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+type tmxUnit struct {
+	SourceLang string       `xml:"srclang,attr,omitempty"`
+	Variants   []tmxVariant `xml:"tuv"`
+}
+
+type tmxVariant struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	// LegacyLang is the attribute used before TMX 1.4.
+	LegacyLang string `xml:"lang,attr,omitempty"`
+	Seg        tmxSeg `xml:"seg"`
+}
+
+func (v *tmxVariant) lang() string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+	return v.LegacyLang
+}
+
+// tmxSeg is the text of a segment, without inline native code.
+type tmxSeg struct {
+	Text string `xml:",chardata"`
+}
+
+func (s *tmxSeg) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	var (
+		sb    strings.Builder
+		depth int
+		skip  int // depth of the native code element being skipped, if any
+	)
+	for {
+		token, err := d.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if skip == 0 && slices.Contains(tmxIgnoreElements, token.Name.Local) {
+				skip = depth
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				s.Text = sb.String()
+				return nil
+			}
+			if depth == skip {
+				skip = 0
+			}
+			depth--
+		case xml.CharData:
+			if skip == 0 {
+				sb.Write(token)
+			}
+		}
+	}
+}
+
+func normalizeTMSourceLang(lang string) string {
+	base, _, _ := strings.Cut(strings.ToUpper(lang), "-")
+	return base
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the
+// length of the longer one, or 0 if it is certainly below minSimilarity.
+func similarity(a, b string, minSimilarity float64) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	// The length difference alone needs that many edits.
+	if 1-float64(abs(len(ra)-len(rb)))/float64(longest) < minSimilarity {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance of a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
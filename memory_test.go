@@ -0,0 +1,140 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslationMemoryLookup(t *testing.T) {
+	tm := NewTranslationMemory(WithFuzzyMatching(0.8))
+	tm.Add("en", "de", "Save changes", "Änderungen speichern")
+	tm.Add("EN-GB", "PT-BR", "Save changes", "Salvar alterações")
+	tm.Add("en", "de", "Save changes", "Änderungen sichern")
+	assert.Equal(t, 2, tm.Len())
+
+	match, ok := tm.Lookup("EN", "DE", "Save changes")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Änderungen sichern", match.Target)
+		assert.Equal(t, 1.0, match.Similarity)
+	}
+
+	match, ok = tm.Lookup("", "pt", "Save changes")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Salvar alterações", match.Target)
+	}
+
+	_, ok = tm.Lookup("en", "PT-PT", "Save changes")
+	assert.False(t, ok)
+
+	match, ok = tm.Lookup("en", "de", "Save change")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Save changes", match.Source)
+		assert.InDelta(t, 11.0/12, match.Similarity, 1e-9)
+	}
+
+	_, ok = tm.Lookup("en", "de", "Discard changes")
+	assert.False(t, ok)
+	_, ok = NewTranslationMemory().Lookup("en", "de", "Save change")
+	assert.False(t, ok)
+}
+
+func TestTranslationMemoryTMX(t *testing.T) {
+	source := `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="test" creationtoolversion="1" segtype="sentence" o-tmf="test" adminlang="en" srclang="en-US" datatype="plaintext"/>
+  <body>
+    <tu>
+      <tuv xml:lang="de-DE"><seg>Hallo <ph x="1">&lt;br/&gt;</ph>Welt</seg></tuv>
+      <tuv xml:lang="en-US"><seg>Hello <ph x="1">&lt;br/&gt;</ph>world</seg></tuv>
+      <tuv xml:lang="fr-FR"><seg>Bonjour le monde</seg></tuv>
+    </tu>
+    <tu srclang="*all*">
+      <tuv lang="en"><seg>Fish &amp; chips</seg></tuv>
+      <tuv lang="de"><seg>Fisch &amp; Pommes</seg></tuv>
+    </tu>
+  </body>
+</tmx>`
+
+	tm := NewTranslationMemory()
+	if !assert.NoError(t, tm.ImportTMX(strings.NewReader(source))) {
+		return
+	}
+	assert.Equal(t, 3, tm.Len())
+
+	match, ok := tm.Lookup("en", "de", "Hello world")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Hallo Welt", match.Target)
+		assert.Equal(t, "DE-DE", match.TargetLang)
+	}
+
+	var buf bytes.Buffer
+	if assert.NoError(t, tm.ExportTMX(&buf)) {
+		assert.Contains(t, buf.String(), `srclang="EN"`)
+		assert.Contains(t, buf.String(), `<tuv xml:lang="FR-FR">`)
+		assert.Contains(t, buf.String(), `<seg>Fisch &amp; Pommes</seg>`)
+
+		imported := NewTranslationMemory()
+		if assert.NoError(t, imported.ImportTMX(&buf)) {
+			assert.Equal(t, tm.Len(), imported.Len())
+			match, ok := imported.Lookup("en", "de", "Fish & chips")
+			assert.True(t, ok)
+			assert.Equal(t, "Fisch & Pommes", match.Target)
+		}
+	}
+}
+
+func TestTranslateWithMemory(t *testing.T) {
+	tm := NewTranslationMemory()
+	tm.Add("en", "de", "Hello", "Hallo")
+
+	for _, version := range []string{"/v1", "/v2"} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+version), WithTranslationMemory(tm))
+
+		text, err := translator.TranslateText("Hello", "DE", WithSourceLang("EN"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Hallo", text)
+		assert.Zero(t, server.requests.Load())
+
+		results, err := translator.translateSegments(context.Background(), []string{"Hello", "World"}, "de")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hallo", "[DE]World"}, results)
+		assert.Equal(t, int64(1), server.requests.Load())
+	}
+}
+
+func TestTranslateWithFuzzyMemory(t *testing.T) {
+	tm := NewTranslationMemory(WithFuzzyMatching(0.8))
+	tm.Add("en", "de", "Delete 5 files", "5 Dateien löschen")
+
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithTranslationMemory(tm))
+
+	results, err := translator.translateSegments(context.Background(), []string{"Delete 5 files", "Delete 6 files"}, "de")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5 Dateien löschen", "[DE]Delete 6 files"}, results)
+	assert.Equal(t, int64(1), server.requests.Load())
+
+	match, ok := tm.Lookup("en", "de", "Delete 6 files")
+	if assert.True(t, ok) {
+		assert.Equal(t, "5 Dateien löschen", match.Target)
+		assert.Less(t, match.Similarity, 1.0)
+	}
+}
+
+func TestWithFuzzyMatchingRange(t *testing.T) {
+	for value, expected := range map[float64]float64{-0.1: 0, 0: 0, 0.5: 0.5, 1: 1, 90: 1} {
+		tm := NewTranslationMemory(WithFuzzyMatching(value))
+		assert.Equal(t, expected, tm.minSimilarity, value)
+	}
+
+	// Out of range, fuzzy matching stays disabled.
+	tm := NewTranslationMemory(WithFuzzyMatching(-1))
+	tm.Add("en", "de", "Save changes", "Änderungen speichern")
+	_, ok := tm.Lookup("en", "de", "Save change")
+	assert.False(t, ok)
+}
@@ -0,0 +1,246 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultLatencyBuckets are the upper bounds in seconds of the request
+// latency histogram, as Prometheus clients use by default.
+var defaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// RequestObservation describes a single HTTP request to the API.
+type RequestObservation struct {
+	Backend    string // host of the base URL
+	Endpoint   string // e.g. "translate" or "usage"
+	TargetLang string // empty for requests other than translations
+	Status     int    // HTTP status code, or 0 if there was no response
+	Duration   time.Duration
+
+	// Characters counts the characters of the text sent, which are billed
+	// if the request succeeds.
+	Characters int64
+}
+
+// MetricsCollector receives the measurements of a Translator. Its methods
+// are called concurrently.
+type MetricsCollector interface {
+	// ObserveRequest is called after every HTTP request to the API.
+	ObserveRequest(RequestObservation)
+	// ObserveCache is called for every lookup in a cache, i.e. "memory" for
+	// the translation memory and "coalesced" for requests that could share
+	// the response of an identical one in flight.
+	ObserveCache(cache string, hit bool)
+	// ObserveKeyExhausted is called when a key of the key pool has exceeded
+	// its quota.
+	ObserveKeyExhausted(backend string)
+}
+
+// WithMetrics makes the Translator report its measurements to the collector.
+func WithMetrics(collector MetricsCollector) TranslatorOption {
+	return func(t *Translator) {
+		t.metrics = collector
+	}
+}
+
+// Metrics is a MetricsCollector keeping its metrics in memory, which serves
+// them in the Prometheus text exposition format as an http.Handler.
+type Metrics struct {
+	mu         sync.Mutex
+	requests   map[[4]string]int64 // by backend, endpoint, target lang and status
+	latencies  map[[3]string]*histogram
+	characters map[[2]string]int64 // billed, by backend and target lang
+	caches     map[[2]string]int64 // by cache and result
+	exhausted  map[string]int64
+}
+
+type histogram struct {
+	counts []int64 // per bucket, not cumulative
+	sum    float64
+	count  int64
+}
+
+// NewMetrics creates a new, empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:   make(map[[4]string]int64),
+		latencies:  make(map[[3]string]*histogram),
+		characters: make(map[[2]string]int64),
+		caches:     make(map[[2]string]int64),
+		exhausted:  make(map[string]int64),
+	}
+}
+
+// ObserveRequest counts the request, its latency and billed characters.
+func (m *Metrics) ObserveRequest(o RequestObservation) {
+	status := "error"
+	if o.Status != 0 {
+		status = strconv.Itoa(o.Status)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[[4]string{o.Backend, o.Endpoint, o.TargetLang, status}]++
+
+	key := [3]string{o.Backend, o.Endpoint, o.TargetLang}
+	h, ok := m.latencies[key]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(defaultLatencyBuckets))}
+		m.latencies[key] = h
+	}
+	seconds := o.Duration.Seconds()
+	if i, _ := slices.BinarySearch(defaultLatencyBuckets, seconds); i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+
+	if o.Status == http.StatusOK && o.Characters > 0 {
+		m.characters[[2]string{o.Backend, o.TargetLang}] += o.Characters
+	}
+}
+
+// ObserveCache counts the cache lookup.
+func (m *Metrics) ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.caches[[2]string{cache, result}]++
+}
+
+// ObserveKeyExhausted counts the exhausted key.
+func (m *Metrics) ObserveKeyExhausted(backend string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exhausted[backend]++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(m.String()))
+}
+
+// String returns the metrics in the Prometheus text exposition format.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+
+	writeHeader(&sb, "deeplx_requests_total", "counter", "Number of API requests.")
+	for _, key := range sortedKeys(m.requests) {
+		writeSample(&sb, "deeplx_requests_total", m.requests[key],
+			"backend", key[0], "endpoint", key[1], "target_lang", key[2], "status", key[3])
+	}
+
+	writeHeader(&sb, "deeplx_request_duration_seconds", "histogram", "Latency of API requests.")
+	for _, key := range sortedKeys(m.latencies) {
+		h := m.latencies[key]
+		labels := []string{"backend", key[0], "endpoint", key[1], "target_lang", key[2]}
+		var cumulative int64
+		for i, bound := range defaultLatencyBuckets {
+			cumulative += h.counts[i]
+			writeSample(&sb, "deeplx_request_duration_seconds_bucket", cumulative,
+				append(labels, "le", strconv.FormatFloat(bound, 'g', -1, 64))...)
+		}
+		writeSample(&sb, "deeplx_request_duration_seconds_bucket", h.count, append(labels, "le", "+Inf")...)
+		writeSample(&sb, "deeplx_request_duration_seconds_sum", h.sum, labels...)
+		writeSample(&sb, "deeplx_request_duration_seconds_count", h.count, labels...)
+	}
+
+	writeHeader(&sb, "deeplx_billed_characters_total", "counter", "Number of characters translated.")
+	for _, key := range sortedKeys(m.characters) {
+		writeSample(&sb, "deeplx_billed_characters_total", m.characters[key],
+			"backend", key[0], "target_lang", key[1])
+	}
+
+	writeHeader(&sb, "deeplx_cache_lookups_total", "counter", "Number of cache lookups.")
+	for _, key := range sortedKeys(m.caches) {
+		writeSample(&sb, "deeplx_cache_lookups_total", m.caches[key], "cache", key[0], "result", key[1])
+	}
+
+	writeHeader(&sb, "deeplx_keys_exhausted_total", "counter", "Number of times a pooled auth key exceeded its quota.")
+	for _, backend := range sortedKeys(m.exhausted) {
+		writeSample(&sb, "deeplx_keys_exhausted_total", m.exhausted[backend], "backend", backend)
+	}
+
+	return sb.String()
+}
+
+func writeHeader(sb *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes a sample with labels given as name and value pairs.
+func writeSample[V int64 | float64](sb *strings.Builder, name string, value V, labels ...string) {
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labels[i] + `="` + metricLabelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		sb.WriteByte('}')
+	}
+	fmt.Fprintf(sb, " %v\n", value)
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	return keys
+}
+
+type requestInfoKey struct{}
+
+// requestInfo describes the translation request an HTTP request is made
+// for, for metrics.
+type requestInfo struct {
+	targetLang string
+	characters int64
+}
+
+func withRequestInfo(ctx context.Context, info requestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// observeRequest reports an HTTP request to the metrics collector, if any.
+func (t *Translator) observeRequest(ctx context.Context, req *http.Request, res *http.Response, latency time.Duration) {
+	if t.metrics == nil {
+		return
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(requestInfo)
+	o := RequestObservation{
+		Backend:    req.URL.Host,
+		Endpoint:   path.Base(req.URL.Path),
+		TargetLang: info.targetLang,
+		Duration:   latency,
+		Characters: info.characters,
+	}
+	if res != nil {
+		o.Status = res.StatusCode
+	}
+	t.metrics.ObserveRequest(o)
+}
@@ -0,0 +1,68 @@
+package deeplx_translator
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	server := newMockServer(t)
+	host, _ := url.Parse(server.URL)
+
+	tm := NewTranslationMemory()
+	tm.Add("", "de", "Hello", "Hallo")
+
+	metrics := NewMetrics()
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithMetrics(metrics), WithTranslationMemory(tm))
+	_, err := translator.TranslateText([]string{"Hello", "world", "world"}, "DE")
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(rec.Body)
+	text := strings.ReplaceAll(string(body), host.Host, "HOST")
+
+	for _, line := range []string{
+		"# TYPE deeplx_requests_total counter",
+		`deeplx_requests_total{backend="HOST",endpoint="translate",target_lang="DE",status="200"} 1`,
+		"# TYPE deeplx_request_duration_seconds histogram",
+		`deeplx_request_duration_seconds_bucket{backend="HOST",endpoint="translate",target_lang="DE",le="+Inf"} 1`,
+		`deeplx_request_duration_seconds_count{backend="HOST",endpoint="translate",target_lang="DE"} 1`,
+		`deeplx_billed_characters_total{backend="HOST",target_lang="DE"} 5`,
+		`deeplx_cache_lookups_total{cache="coalesced",result="miss"} 1`,
+		`deeplx_cache_lookups_total{cache="memory",result="hit"} 1`,
+		`deeplx_cache_lookups_total{cache="memory",result="miss"} 2`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+}
+
+func TestMetricsHistogram(t *testing.T) {
+	metrics := NewMetrics()
+	for _, d := range []time.Duration{time.Millisecond, 30 * time.Millisecond, 30 * time.Second} {
+		metrics.ObserveRequest(RequestObservation{Backend: `a"b`, Endpoint: "usage", Duration: d})
+	}
+	metrics.ObserveKeyExhausted("api-free.deepl.com")
+
+	text := metrics.String()
+	for _, line := range []string{
+		`deeplx_requests_total{backend="a\"b",endpoint="usage",target_lang="",status="error"} 3`,
+		`deeplx_request_duration_seconds_bucket{backend="a\"b",endpoint="usage",target_lang="",le="0.005"} 1`,
+		`deeplx_request_duration_seconds_bucket{backend="a\"b",endpoint="usage",target_lang="",le="0.025"} 1`,
+		`deeplx_request_duration_seconds_bucket{backend="a\"b",endpoint="usage",target_lang="",le="0.05"} 2`,
+		`deeplx_request_duration_seconds_bucket{backend="a\"b",endpoint="usage",target_lang="",le="10"} 2`,
+		`deeplx_request_duration_seconds_bucket{backend="a\"b",endpoint="usage",target_lang="",le="+Inf"} 3`,
+		`deeplx_request_duration_seconds_sum{backend="a\"b",endpoint="usage",target_lang=""} 30.031`,
+		`deeplx_keys_exhausted_total{backend="api-free.deepl.com"} 1`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+	assert.NotContains(t, text, "deeplx_billed_characters_total{")
+}
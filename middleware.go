@@ -0,0 +1,55 @@
+package deeplx_translator
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TranslateRequest is a single translation request as seen by middleware.
+// Middleware may change it before passing it on.
+type TranslateRequest struct {
+	Text       any // string with v1, []string with v2
+	TargetLang string
+	Options    TranslateOptions
+	Version    Version // the API version, for information only
+
+	// Header holds extra headers to send with the request.
+	Header http.Header
+}
+
+// TranslateResponse is the response to a TranslateRequest.
+type TranslateResponse struct {
+	Result   any // *TranslationResultV1 or *TranslationResultV2
+	Duration time.Duration
+}
+
+// TranslateHandler handles a translation request. The handler sending the
+// request to the API returns a response, with its duration, even when it
+// fails.
+type TranslateHandler func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error)
+
+// Middleware wraps a TranslateHandler, e.g. to log, measure, cache or
+// rewrite translation requests. It sees every request sent to the translate
+// endpoint, after text has been split, batched and looked up in the
+// translation memory, and before requests are coalesced, budgeted and sent.
+// Middleware does not see the requests of a DryRun.
+type Middleware func(next TranslateHandler) TranslateHandler
+
+// WithMiddleware adds middleware around every translation request. The
+// first middleware added is the outermost one.
+func WithMiddleware(middleware ...Middleware) TranslatorOption {
+	return func(t *Translator) {
+		t.middleware = append(t.middleware, middleware...)
+	}
+}
+
+// translateHandler returns the handler for translation requests, wrapped
+// in all middleware.
+func (t *Translator) translateHandler() TranslateHandler {
+	handler := t.sendTranslateRequest
+	for i := len(t.middleware) - 1; i >= 0; i-- {
+		handler = t.middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,90 @@
+package deeplx_translator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := newMockServer(t)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next TranslateHandler) TranslateHandler {
+			return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+	rewrite := func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			assert.Equal(t, VersionV2, req.Version)
+			assert.Equal(t, "EN", *req.Options.SourceLang)
+			req.Text = []string{"Rewritten"}
+			req.Header.Set("X-Request-ID", "42")
+			resp, err := next(ctx, req)
+			if assert.NoError(t, err) {
+				assert.Positive(t, resp.Duration)
+			}
+			return resp, err
+		}
+	}
+
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"),
+		WithMiddleware(trace("outer"), trace("inner")), WithMiddleware(rewrite))
+	text, err := translator.TranslateText([]string{"Hello"}, "DE", WithSourceLang("EN"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[DE]Rewritten", text)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	assert.Equal(t, "42", server.lastRequestHeader().Get("X-Request-ID"))
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	server := newMockServer(t)
+
+	cache := func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			if req.Text == "cached" {
+				return &TranslateResponse{Result: &TranslationResultV1{Data: "aus dem Cache"}}, nil
+			}
+			if req.Text == "broken" {
+				return &TranslateResponse{Result: "nonsense"}, nil
+			}
+			if req.Text == "empty" {
+				return nil, nil
+			}
+			return next(ctx, req)
+		}
+	}
+
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"), WithMiddleware(cache))
+	text, err := translator.TranslateText("cached", "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, "aus dem Cache", text)
+	assert.Zero(t, server.requests.Load())
+
+	_, err = translator.TranslateText("broken", "DE")
+	assert.EqualError(t, err, "invalid response type: string")
+	_, err = translator.TranslateText("empty", "DE")
+	assert.EqualError(t, err, "middleware returned no response")
+
+	// The response reports the duration of failed requests, too.
+	failing := NewTranslator("", WithBaseURL("http://127.0.0.1:0/v1"), WithMiddleware(func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			resp, err := next(ctx, req)
+			assert.Error(t, err)
+			if assert.NotNil(t, resp) {
+				assert.Nil(t, resp.Result)
+			}
+			return nil, errors.New("wrapped")
+		}
+	}))
+	_, err = failing.TranslateText("Hello", "DE")
+	assert.EqualError(t, err, "wrapped")
+}
@@ -2,6 +2,8 @@ package deeplx_translator
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 )
 
 type TranslateOptions struct {
@@ -15,6 +17,19 @@ type TranslateOptions struct {
 	NonSplittingTags   []*string `json:"non_splitting_tags,omitempty"`
 	SplittingTags      []*string `json:"splitting_tags,omitempty"`
 	IgnoreTags         []*string `json:"ignore_tags,omitempty"`
+	Context            *string   `json:"context,omitempty"`
+
+	ModelType            *string   `json:"model_type,omitempty"`
+	ShowBilledCharacters *bool     `json:"show_billed_characters,omitempty"`
+	TagHandlingVersion   *string   `json:"tag_handling_version,omitempty"`
+	CustomInstructions   []*string `json:"custom_instructions,omitempty"`
+
+	tenant      string // budget tenant, never sent
+	autoContext int    // characters of preceding text to send as context
+
+	// chunked tells that segments are XML-escaped and sent with XML tag
+	// handling, so that v1 may pack several of them into one request.
+	chunked bool
 }
 
 func (o *TranslateOptions) Gather(opts ...TranslateOption) error {
@@ -29,6 +44,14 @@ func (o *TranslateOptions) Gather(opts ...TranslateOption) error {
 // TranslateOption can be used to customize the translation engine.
 type TranslateOption func(*TranslateOptions) error
 
+// withTranslateOptions replaces all options with the already gathered ones.
+func withTranslateOptions(value TranslateOptions) TranslateOption {
+	return func(o *TranslateOptions) error {
+		*o = value
+		return nil
+	}
+}
+
 // WithSourceLang specifies the language of the text to be translated.
 // If this parameter is omitted, the API will attempt to detect the language of the text and translate it
 func WithSourceLang(value string) TranslateOption {
@@ -148,6 +171,24 @@ func WithTagHandling(value string) TranslateOption {
 	}
 }
 
+// WithTagHandlingVersion sets the version of the tag handling algorithm used
+// with WithTagHandling.
+//
+// Possible values are:
+//   - `v1` - the original algorithm
+//   - `v2` - the improved algorithm, which better handles tags within and
+//     across sentences
+func WithTagHandlingVersion(value string) TranslateOption {
+	return func(o *TranslateOptions) error {
+		switch value {
+		case "v1", "v2":
+			o.TagHandlingVersion = &value
+			return nil
+		}
+		return translateOptionInvalidValueError("tag_handling_version", value)
+	}
+}
+
 // WithOutlineDetection can be used to disable the automatic detection of the
 // XML structure.
 //
@@ -286,6 +327,110 @@ func WithIgnoreTags(value []string) TranslateOption {
 	}
 }
 
+// WithContext sets additional context that can influence a translation but
+// is not translated itself, e.g. the surroundings of a short segment.
+// Characters sent in the context parameter are not billed.
+func WithContext(value string) TranslateOption {
+	return func(o *TranslateOptions) error {
+		o.Context = &value
+		return nil
+	}
+}
+
+// WithAutoContext makes segmented text be sent with up to limit characters
+// of the text preceding each batch as context, so that segments do not lose
+// their surroundings when text is split up. Any context set with WithContext
+// comes first.
+//
+// It only has an effect with v2, when text is translated in several batches,
+// e.g. long text or documents. The first batch has no preceding text, so it
+// is only sent with the context set with WithContext, if any. TranslateTextV2
+// sends text as a single request, so the option has no effect there either.
+func WithAutoContext(limit int) TranslateOption {
+	return func(o *TranslateOptions) error {
+		if limit <= 0 {
+			return translateOptionInvalidValueError("context", fmt.Sprintf("auto context limit %d", limit))
+		}
+		o.autoContext = limit
+		return nil
+	}
+}
+
+// WithModelType sets which kind of model is used for the translation.
+//
+// Possible values are:
+//   - `quality_optimized` - use the highest-quality model, failing for
+//     language pairs it does not support
+//   - `prefer_quality_optimized` - use the highest-quality model where
+//     available, otherwise fall back to the latency-optimized one
+//   - `latency_optimized` - use the classic, lower-latency model
+//
+// The model used is returned in TranslationV2.ModelTypeUsed.
+// `latency_optimized` cannot be combined with WithCustomInstructions.
+func WithModelType(value string) TranslateOption {
+	return func(o *TranslateOptions) error {
+		switch value {
+		case "quality_optimized", "prefer_quality_optimized", "latency_optimized":
+			if value == "latency_optimized" && len(o.CustomInstructions) > 0 {
+				return fmt.Errorf("option `model_type=latency_optimized` cannot be combined with `custom_instructions`")
+			}
+			o.ModelType = &value
+			return nil
+		}
+		return translateOptionInvalidValueError("model_type", value)
+	}
+}
+
+// WithShowBilledCharacters sets whether the characters billed for each text
+// are returned in TranslationV2.BilledCharacters.
+func WithShowBilledCharacters(value bool) TranslateOption {
+	return func(o *TranslateOptions) error {
+		o.ShowBilledCharacters = &value
+		return nil
+	}
+}
+
+const (
+	maxCustomInstructions      = 10
+	maxCustomInstructionLength = 300
+)
+
+// WithCustomInstructions specifies instructions in plain language for the
+// translation, e.g. "Use a friendly tone". Up to 10 instructions of up to
+// 300 characters each are accepted.
+//
+// Custom instructions require the quality-optimized model, so they cannot be
+// combined with `model_type=latency_optimized`.
+func WithCustomInstructions(value []string) TranslateOption {
+	return func(o *TranslateOptions) error {
+		if o.ModelType != nil && *o.ModelType == "latency_optimized" {
+			return fmt.Errorf("option `custom_instructions` cannot be combined with `model_type=latency_optimized`")
+		}
+		if len(o.CustomInstructions)+len(value) > maxCustomInstructions {
+			return translateOptionInvalidValueError("custom_instructions",
+				fmt.Sprintf("more than %d instructions", maxCustomInstructions))
+		}
+		for _, v := range value {
+			if strings.TrimSpace(v) == "" || utf8.RuneCountInString(v) > maxCustomInstructionLength {
+				return translateOptionInvalidValueError("custom_instructions", v)
+			}
+		}
+		for _, v := range value {
+			o.CustomInstructions = append(o.CustomInstructions, &v)
+		}
+		return nil
+	}
+}
+
+// WithTenant sets the tenant whose budget the request counts against, see
+// WithBudget. The tenant is not sent to the API.
+func WithTenant(value string) TranslateOption {
+	return func(o *TranslateOptions) error {
+		o.tenant = value
+		return nil
+	}
+}
+
 func translateOptionInvalidValueError(name string, value string) error {
 	return fmt.Errorf("invalid value for option `%s`: %s", name, value)
 }
@@ -0,0 +1,189 @@
+package deeplx_translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// placeholderTag is the XML tag used to mark protected placeholders, it is
+// sent as an ignore tag so the engine copies its content verbatim.
+const placeholderTag = "x-ph"
+
+// ErrPlaceholderLost is returned when a protected placeholder is missing from
+// the translated text.
+var ErrPlaceholderLost = errors.New("placeholder lost in translation")
+
+var (
+	// printfVerbRegexp matches printf-style verbs such as %s, %5.2f, %[1]d,
+	// and the positional and Apple flavours %1$s, %@, %lld and %#@name@.
+	// The space flag is left out on purpose, as "50% off" is far more common
+	// in prose than "% d".
+	printfVerbRegexp = regexp.MustCompile(`^%#@\w+@|^%(?:\[\d+\]|\d+\$)?[-+#0]*(?:\*|\d+)?(?:\.(?:\*|\d+)?)?(?:\[\d+\])?(?:hh|h|ll|l|q|z|t|j|L)?[a-zA-Z@%]`)
+
+	// placeholderMarkerRegexp matches the markers emitted by protectPlaceholders.
+	placeholderMarkerRegexp = regexp.MustCompile(`<` + placeholderTag + ` id="(\d+)">.*?</` + placeholderTag + `>`)
+
+	xmlEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'")
+)
+
+// TranslateProtected translates text while protecting placeholders from the
+// translation engine.
+//
+// Printf verbs (%s, %[1]d), Go template actions ({{.Count}}), ICU arguments
+// including plurals ({name}, {n, plural, one {# item} other {# items}}) and
+// inline code (`code`) are replaced with ignore-tag markers before the text is
+// sent with XML tag handling, and restored afterwards. An error wrapping
+// ErrPlaceholderLost is returned if any placeholder does not survive.
+//
+// Unless tag handling has been set explicitly, text is treated as plain text
+// and escaped accordingly.
+func (t *Translator) TranslateProtected(ctx context.Context, text string, targetLang string, opts ...TranslateOption) (string, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return "", fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	escape := o.TagHandling == nil
+	protected, tokens := protectPlaceholders(text, escape)
+	if len(tokens) == 0 {
+		return t.TranslateTextContext(ctx, text, targetLang, withTranslateOptions(o))
+	}
+
+	// The protected text is sent as a single segment, as splitting it at
+	// sentence terminators could cut through markers, e.g. of {{.Count}}.
+	result, err := t.TranslateTextContext(ctx, []string{protected}, targetLang, withTranslateOptions(protectOptions(o, escape)))
+	if err != nil {
+		return "", err
+	}
+	return restorePlaceholders(result, tokens, escape)
+}
+
+// protectOptions returns a copy of o set up for sending protected text, i.e.
+// with XML tag handling if the text has been escaped and with the marker tag
+// ignored.
+func protectOptions(o TranslateOptions, escaped bool) TranslateOptions {
+	if escaped {
+		tagHandling := "xml"
+		o.TagHandling = &tagHandling
+	}
+	tag := placeholderTag
+	o.IgnoreTags = append(slices.Clip(o.IgnoreTags), &tag)
+	return o
+}
+
+// protectPlaceholders replaces every placeholder in text with a numbered
+// marker and returns the placeholders in order of appearance. If escape is
+// set, the remaining text is XML-escaped.
+func protectPlaceholders(text string, escape bool) (string, []string) {
+	return protectTokens(text, escape, placeholderLen)
+}
+
+// protectTokens is like protectPlaceholders, but with tokenLen reporting the
+// length of the token at the start of a string, or 0 if there is none.
+func protectTokens(text string, escape bool, tokenLen func(string) int) (string, []string) {
+	var (
+		sb     strings.Builder
+		tokens []string
+		plain  int // start of pending plain text
+	)
+	flush := func(end int) {
+		if escape {
+			sb.WriteString(xmlEscaper.Replace(text[plain:end]))
+		} else {
+			sb.WriteString(text[plain:end])
+		}
+	}
+	for i := 0; i < len(text); {
+		n := tokenLen(text[i:])
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		flush(i)
+
+		token := text[i : i+n]
+		fmt.Fprintf(&sb, `<%s id="%d">%s</%s>`, placeholderTag, len(tokens), xmlEscaper.Replace(token), placeholderTag)
+		tokens = append(tokens, token)
+		i += n
+		plain = i
+	}
+	flush(len(text))
+	return sb.String(), tokens
+}
+
+// restorePlaceholders reverses protectPlaceholders on translated text.
+func restorePlaceholders(text string, tokens []string, unescape bool) (string, error) {
+	seen := make([]bool, len(tokens))
+
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, m := range placeholderMarkerRegexp.FindAllStringSubmatchIndex(text, -1) {
+		id, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || id >= len(tokens) {
+			return "", fmt.Errorf("unknown placeholder marker: %s", text[m[0]:m[1]])
+		}
+		seen[id] = true
+
+		if unescape {
+			sb.WriteString(xmlUnescaper.Replace(text[last:m[0]]))
+		} else {
+			sb.WriteString(text[last:m[0]])
+		}
+		sb.WriteString(tokens[id])
+		last = m[1]
+	}
+	if unescape {
+		sb.WriteString(xmlUnescaper.Replace(text[last:]))
+	} else {
+		sb.WriteString(text[last:])
+	}
+
+	for id, ok := range seen {
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrPlaceholderLost, tokens[id])
+		}
+	}
+	return sb.String(), nil
+}
+
+// placeholderLen returns the length of the placeholder at the start of s,
+// or 0 if s does not start with one.
+func placeholderLen(s string) int {
+	switch {
+	case strings.HasPrefix(s, "{{"):
+		if end := strings.Index(s[2:], "}}"); end >= 0 {
+			return end + 4
+		}
+	case strings.HasPrefix(s, "{"):
+		// ICU arguments may nest, e.g. {n, plural, one {# item} other {# items}}.
+		depth := 0
+		for i, r := range s {
+			switch r {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+	case strings.HasPrefix(s, "`"):
+		if end := strings.IndexByte(s[1:], '`'); end > 0 {
+			return end + 2
+		}
+	case strings.HasPrefix(s, "%"):
+		return len(printfVerbRegexp.FindString(s))
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtectPlaceholders(t *testing.T) {
+	tests := []struct {
+		text      string
+		protected string
+		tokens    []string
+	}{
+		{
+			text:      "Hello, world!",
+			protected: "Hello, world!",
+			tokens:    nil,
+		},
+		{
+			text:      "Hello %s, you have %[1]d new <b>messages</b> (100%%)",
+			protected: `Hello <x-ph id="0">%s</x-ph>, you have <x-ph id="1">%[1]d</x-ph> new &lt;b&gt;messages&lt;/b&gt; (100<x-ph id="2">%%</x-ph>)`,
+			tokens:    []string{"%s", "%[1]d", "%%"},
+		},
+		{
+			text:      "%1$s sent %lld files to %@ %#@items@",
+			protected: `<x-ph id="0">%1$s</x-ph> sent <x-ph id="1">%lld</x-ph> files to <x-ph id="2">%@</x-ph> <x-ph id="3">%#@items@</x-ph>`,
+			tokens:    []string{"%1$s", "%lld", "%@", "%#@items@"},
+		},
+		{
+			text:      "Hi {name}, {{.Count}} items, {n, plural, one {# file} other {# files}}",
+			protected: `Hi <x-ph id="0">{name}</x-ph>, <x-ph id="1">{{.Count}}</x-ph> items, <x-ph id="2">{n, plural, one {# file} other {# files}}</x-ph>`,
+			tokens:    []string{"{name}", "{{.Count}}", "{n, plural, one {# file} other {# files}}"},
+		},
+		{
+			text:      "Run `go test` & relax { 50% off",
+			protected: `Run <x-ph id="0">` + "`go test`" + `</x-ph> &amp; relax { 50% off`,
+			tokens:    []string{"`go test`"},
+		},
+	}
+	for _, tt := range tests {
+		protected, tokens := protectPlaceholders(tt.text, true)
+		assert.Equal(t, tt.protected, protected)
+		assert.Equal(t, tt.tokens, tokens)
+
+		restored, err := restorePlaceholders(protected, tokens, true)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tt.text, restored)
+		}
+	}
+}
+
+func TestRestorePlaceholdersLost(t *testing.T) {
+	_, err := restorePlaceholders(`Hallo <x-ph id="0">%s</x-ph>`, []string{"%s", "%d"}, true)
+	assert.ErrorIs(t, err, ErrPlaceholderLost)
+}
+
+func TestTranslateProtected(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"))
+
+	result, err := translator.TranslateProtected(context.Background(), "Hello %s & {name}", "de")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[DE]Hello %s & {name}", result)
+	}
+	assert.Equal(t, "xml", server.lastRequest()["tag_handling"])
+	assert.Equal(t, []any{placeholderTag}, server.lastRequest()["ignore_tags"])
+}
+
+func TestTranslateProtectedLongText(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	// Text this long would be split at sentence terminators, which must not
+	// cut through the markers.
+	text := strings.Repeat("You have {{.Count}} items at %5.2f. ", 30)
+	result, err := translator.TranslateProtected(context.Background(), text, "de")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[DE]"+text, result)
+	}
+	if texts, ok := server.lastRequest()["text"].([]any); assert.True(t, ok) && assert.Len(t, texts, 1) {
+		assert.Contains(t, texts[0], `<x-ph id="0">{{.Count}}</x-ph>`)
+		assert.Contains(t, texts[0], `<x-ph id="1">%5.2f</x-ph>`)
+	}
+}
@@ -0,0 +1,64 @@
+package deeplx_translator
+
+import (
+	"strings"
+)
+
+// cldrPluralCategories maps DeepL target languages to the CLDR plural
+// categories of cardinal numbers used by string resources.
+var cldrPluralCategories = map[string][]string{
+	"AR": {"zero", "one", "two", "few", "many", "other"},
+	"BG": {"one", "other"},
+	"CS": {"one", "few", "many", "other"},
+	"DA": {"one", "other"},
+	"DE": {"one", "other"},
+	"EL": {"one", "other"},
+	"EN": {"one", "other"},
+	"ES": {"one", "many", "other"},
+	"ET": {"one", "other"},
+	"FI": {"one", "other"},
+	"FR": {"one", "many", "other"},
+	"HE": {"one", "two", "other"},
+	"HU": {"one", "other"},
+	"ID": {"other"},
+	"IT": {"one", "many", "other"},
+	"JA": {"other"},
+	"KO": {"other"},
+	"LT": {"one", "few", "many", "other"},
+	"LV": {"zero", "one", "other"},
+	"NB": {"one", "other"},
+	"NL": {"one", "other"},
+	"PL": {"one", "few", "many", "other"},
+	"PT": {"one", "many", "other"},
+	"RO": {"one", "few", "other"},
+	"RU": {"one", "few", "many", "other"},
+	"SK": {"one", "few", "many", "other"},
+	"SL": {"one", "two", "few", "other"},
+	"SV": {"one", "other"},
+	"TH": {"other"},
+	"TR": {"one", "other"},
+	"UK": {"one", "few", "many", "other"},
+	"VI": {"other"},
+	"ZH": {"other"},
+}
+
+// pluralCategories returns the plural categories of the target language, in
+// CLDR order, or nil if the language is unknown.
+func pluralCategories(targetLang string) []string {
+	base, _, _ := strings.Cut(strings.ToUpper(targetLang), "-")
+	return cldrPluralCategories[base]
+}
+
+// pluralTemplate returns the category whose text is copied to fill in a
+// category missing from the source, i.e. "other", or the last one present.
+func pluralTemplate(present []string) string {
+	for _, category := range present {
+		if category == "other" {
+			return category
+		}
+	}
+	if len(present) == 0 {
+		return ""
+	}
+	return present[len(present)-1]
+}
@@ -0,0 +1,384 @@
+package deeplx_translator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var poPluralFormsRegexp = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// POFile is a gettext PO or POT catalog.
+type POFile struct {
+	Entries []*POEntry
+}
+
+// POEntry is a single message of a PO file, the header being the entry with
+// an empty ID.
+type POEntry struct {
+	// Comments holds the raw comment lines other than flags, e.g.
+	// "# translator comment", "#. extracted comment" or "#: file.go:12".
+	Comments []string
+	Flags    []string
+
+	Context   *string
+	ID        string
+	IDPlural  *string
+	Str       string
+	StrPlural []string
+
+	commentOnly bool // obsolete entries and trailing comments
+	flagsAt     int  // 1 + number of comments read before the flags, if any
+}
+
+// IsTranslated reports whether the entry has a translation.
+func (e *POEntry) IsTranslated() bool {
+	if e.IDPlural != nil {
+		return slices.ContainsFunc(e.StrPlural, func(s string) bool { return s != "" })
+	}
+	return e.Str != ""
+}
+
+// HasFlag reports whether the entry is marked with flag, e.g. "fuzzy".
+func (e *POEntry) HasFlag(flag string) bool {
+	return slices.Contains(e.Flags, flag)
+}
+
+// Header returns the header entry of the catalog, if any.
+func (f *POFile) Header() *POEntry {
+	for _, entry := range f.Entries {
+		if !entry.commentOnly && entry.ID == "" && entry.Context == nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// PluralForms returns the number of plural forms declared in the header,
+// defaulting to 2.
+func (f *POFile) PluralForms() int {
+	if header := f.Header(); header != nil {
+		if m := poPluralFormsRegexp.FindStringSubmatch(header.Str); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 2
+}
+
+// ParsePO reads a gettext PO or POT file.
+func ParsePO(r io.Reader) (*POFile, error) {
+	var (
+		f       = &POFile{}
+		entry   *POEntry
+		current *string // string that continuation lines are appended to
+		keyword string  // last keyword of the current entry
+		reader  = bufio.NewReader(r)
+		lineNo  int
+	)
+	newEntry := func() {
+		entry = &POEntry{commentOnly: true}
+		f.Entries = append(f.Entries, entry)
+		current, keyword = nil, ""
+	}
+
+	for {
+		// Lines are read whole, as messages can be of any length.
+		raw, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error reading PO file: %w", err)
+		}
+		if raw == "" && err == io.EOF {
+			break
+		}
+		lineNo++
+		line := strings.TrimSpace(raw)
+
+		if line == "" {
+			entry, current, keyword = nil, nil, ""
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			// Comments precede the message, so they start a new entry once
+			// the current one has a message.
+			if entry == nil || !entry.commentOnly {
+				newEntry()
+			}
+			if strings.HasPrefix(line, "#,") {
+				if entry.flagsAt == 0 {
+					entry.flagsAt = len(entry.Comments) + 1
+				}
+				for _, flag := range strings.Split(line[2:], ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						entry.Flags = append(entry.Flags, flag)
+					}
+				}
+			} else {
+				entry.Comments = append(entry.Comments, line)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			if current == nil {
+				return nil, fmt.Errorf("line %d: unexpected string continuation", lineNo)
+			}
+			s, err := unquotePOString(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			*current += s
+			continue
+		}
+
+		word, value, _ := strings.Cut(line, " ")
+		s, err := unquotePOString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		// A message starts with msgctxt, or with msgid unless it follows msgctxt.
+		if (word == "msgctxt" || word == "msgid" && keyword != "msgctxt") &&
+			(entry == nil || !entry.commentOnly) {
+			newEntry()
+		}
+		if entry == nil || (entry.commentOnly && word != "msgctxt" && word != "msgid") {
+			return nil, fmt.Errorf("line %d: unexpected keyword %s", lineNo, word)
+		}
+		entry.commentOnly = false
+		keyword = word
+
+		switch {
+		case word == "msgctxt":
+			entry.Context = &s
+			current = entry.Context
+		case word == "msgid":
+			entry.ID = s
+			current = &entry.ID
+		case word == "msgid_plural":
+			entry.IDPlural = &s
+			current = entry.IDPlural
+		case word == "msgstr":
+			entry.Str = s
+			current = &entry.Str
+		case strings.HasPrefix(word, "msgstr[") && strings.HasSuffix(word, "]"):
+			n, err := strconv.Atoi(word[len("msgstr[") : len(word)-1])
+			if err != nil || n != len(entry.StrPlural) {
+				return nil, fmt.Errorf("line %d: invalid plural index %s", lineNo, word)
+			}
+			entry.StrPlural = append(entry.StrPlural, s)
+			current = &entry.StrPlural[n]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %s", lineNo, word)
+		}
+	}
+	return f, nil
+}
+
+// WriteTo writes the catalog in PO format.
+func (f *POFile) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for i, entry := range f.Entries {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		// Comments keep the order they were read in. Flags go back where
+		// they were read, or else before previous messages and obsolete
+		// entries.
+		flagsAt := entry.flagsAt - 1
+		if flagsAt < 0 || flagsAt > len(entry.Comments) {
+			flagsAt = slices.IndexFunc(entry.Comments, func(comment string) bool {
+				return poCommentOrder(comment) > poCommentOrder("#,")
+			})
+			if flagsAt < 0 {
+				flagsAt = len(entry.Comments)
+			}
+		}
+		writeFlags := func() {
+			if len(entry.Flags) > 0 {
+				sb.WriteString("#, " + strings.Join(entry.Flags, ", ") + "\n")
+			}
+		}
+		for i, comment := range entry.Comments {
+			if i == flagsAt {
+				writeFlags()
+			}
+			sb.WriteString(comment + "\n")
+		}
+		if flagsAt == len(entry.Comments) {
+			writeFlags()
+		}
+
+		if entry.commentOnly {
+			continue
+		}
+		if entry.Context != nil {
+			writePOString(&sb, "msgctxt", *entry.Context)
+		}
+		writePOString(&sb, "msgid", entry.ID)
+		if entry.IDPlural != nil {
+			writePOString(&sb, "msgid_plural", *entry.IDPlural)
+			for n, s := range entry.StrPlural {
+				writePOString(&sb, fmt.Sprintf("msgstr[%d]", n), s)
+			}
+		} else {
+			writePOString(&sb, "msgstr", entry.Str)
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// TranslateCatalog fills in the translations of all untranslated entries of
+// the catalog, including their plural forms, and marks them as fuzzy so
+// they get reviewed. Placeholders such as printf verbs are protected.
+//
+// For plural entries, the first form is translated from msgid and the others
+// from msgid_plural, as many as the header's Plural-Forms declares. With a
+// single plural form, msgid_plural is used.
+func (t *Translator) TranslateCatalog(ctx context.Context, catalog *POFile, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	var (
+		entries  []*POEntry
+		segments []string
+		tokens   [][]string
+	)
+	add := func(text string) {
+		protected, placeholders := protectPlaceholders(text, true)
+		segments = append(segments, protected)
+		tokens = append(tokens, placeholders)
+	}
+	for _, entry := range catalog.Entries {
+		if entry.commentOnly || entry.ID == "" || entry.IsTranslated() {
+			continue
+		}
+		entries = append(entries, entry)
+		add(entry.ID)
+		if entry.IDPlural != nil {
+			add(*entry.IDPlural)
+		}
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(protectOptions(o, true)))
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		if results[i], err = restorePlaceholders(results[i], tokens[i], true); err != nil {
+			return err
+		}
+	}
+
+	nplurals := catalog.PluralForms()
+	for _, entry := range entries {
+		singular := results[0]
+		results = results[1:]
+
+		if entry.IDPlural == nil {
+			entry.Str = singular
+		} else {
+			plural := results[0]
+			results = results[1:]
+
+			entry.StrPlural = make([]string, nplurals)
+			for n := range entry.StrPlural {
+				entry.StrPlural[n] = plural
+			}
+			if nplurals > 1 {
+				entry.StrPlural[0] = singular
+			}
+		}
+
+		if !entry.HasFlag("fuzzy") {
+			entry.Flags = append([]string{"fuzzy"}, entry.Flags...)
+		}
+	}
+	return nil
+}
+
+func poCommentOrder(comment string) int {
+	switch {
+	case strings.HasPrefix(comment, "#."):
+		return 1
+	case strings.HasPrefix(comment, "#:"):
+		return 2
+	case strings.HasPrefix(comment, "#,"):
+		return 3
+	case strings.HasPrefix(comment, "#|"):
+		return 4
+	case strings.HasPrefix(comment, "#~"):
+		return 5
+	default: // translator comments
+		return 0
+	}
+}
+
+func writePOString(sb *strings.Builder, keyword string, s string) {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		sb.WriteString(keyword + " " + quotePOString(s) + "\n")
+		return
+	}
+	sb.WriteString(keyword + " \"\"\n")
+	for _, line := range lines {
+		sb.WriteString(quotePOString(line) + "\n")
+	}
+}
+
+var poEscaper = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+)
+
+func quotePOString(s string) string {
+	return `"` + poEscaper.Replace(s) + `"`
+}
+
+func unquotePOString(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		default: // \\, \" and anything unknown
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,138 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const poSample = `# German translations for example.
+msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+# Shown on the start page.
+#. TRANSLATORS: keep it short
+#: main.go:12
+#, c-format
+msgid "Hello %s!"
+msgstr ""
+
+#: main.go:20
+msgctxt "menu"
+msgid "Open"
+msgstr "Öffnen"
+
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid ""
+"Multi\n"
+"line"
+msgstr ""
+
+#~ msgid "Obsolete"
+#~ msgstr "Veraltet"
+`
+
+func TestParsePO(t *testing.T) {
+	catalog, err := ParsePO(strings.NewReader(poSample))
+	if !assert.NoError(t, err) || !assert.Len(t, catalog.Entries, 6) {
+		return
+	}
+	assert.Equal(t, 2, catalog.PluralForms())
+
+	entry := catalog.Entries[1]
+	assert.Equal(t, "Hello %s!", entry.ID)
+	assert.Equal(t, []string{"c-format"}, entry.Flags)
+	assert.Equal(t, []string{"# Shown on the start page.", "#. TRANSLATORS: keep it short", "#: main.go:12"}, entry.Comments)
+	assert.False(t, entry.IsTranslated())
+
+	entry = catalog.Entries[2]
+	if assert.NotNil(t, entry.Context) {
+		assert.Equal(t, "menu", *entry.Context)
+	}
+	assert.True(t, entry.IsTranslated())
+
+	entry = catalog.Entries[3]
+	if assert.NotNil(t, entry.IDPlural) {
+		assert.Equal(t, "%d files", *entry.IDPlural)
+	}
+	assert.Equal(t, []string{"", ""}, entry.StrPlural)
+
+	assert.Equal(t, "Multi\nline", catalog.Entries[4].ID)
+
+	buf := &bytes.Buffer{}
+	_, err = catalog.WriteTo(buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, poSample, buf.String())
+	}
+}
+
+func TestTranslateCatalog(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	catalog, err := ParsePO(strings.NewReader(poSample))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, translator.TranslateCatalog(context.Background(), catalog, "de")) {
+		return
+	}
+
+	assert.Equal(t, "[DE]Hello %s!", catalog.Entries[1].Str)
+	assert.Equal(t, []string{"fuzzy", "c-format"}, catalog.Entries[1].Flags)
+	assert.Equal(t, "Öffnen", catalog.Entries[2].Str)
+	assert.Empty(t, catalog.Entries[2].Flags)
+	assert.Equal(t, []string{"[DE]%d file", "[DE]%d files"}, catalog.Entries[3].StrPlural)
+	assert.Equal(t, "[DE]Multi\nline", catalog.Entries[4].Str)
+	assert.EqualValues(t, 1, server.requests.Load())
+}
+
+func TestParsePOCommentOrder(t *testing.T) {
+	const sample = `#: main.go:12
+# Shown on the start page.
+#, c-format
+#. TRANSLATORS: keep it short
+#| msgid "Hello!"
+msgid "Hello %s!"
+msgstr ""
+`
+	catalog, err := ParsePO(strings.NewReader(sample))
+	if !assert.NoError(t, err) {
+		return
+	}
+	buf := &bytes.Buffer{}
+	_, err = catalog.WriteTo(buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, sample, buf.String())
+	}
+
+	// Flags added in code go before previous messages.
+	entry := &POEntry{
+		Comments: []string{"#: main.go:12", `#| msgid "Hello!"`},
+		Flags:    []string{"fuzzy"},
+		ID:       "Hello %s!",
+	}
+	buf.Reset()
+	_, err = (&POFile{Entries: []*POEntry{entry}}).WriteTo(buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "#: main.go:12\n#, fuzzy\n#| msgid \"Hello!\"\nmsgid \"Hello %s!\"\nmsgstr \"\"\n", buf.String())
+	}
+}
+
+func TestParsePOLongLine(t *testing.T) {
+	long := strings.Repeat("a", 100*1024)
+	catalog, err := ParsePO(strings.NewReader("msgid \"" + long + "\"\nmsgstr \"\""))
+	if assert.NoError(t, err) && assert.Len(t, catalog.Entries, 1) {
+		assert.Equal(t, long, catalog.Entries[0].ID)
+	}
+}
@@ -0,0 +1,184 @@
+package deeplx_translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// RephraseResult is the response of the DeepL Write API.
+type RephraseResult struct {
+	Improvements []Improvement `json:"improvements"`
+}
+
+// Improvement is the improved version of a single text.
+type Improvement struct {
+	Text                   string `json:"text"`
+	TargetLanguage         string `json:"target_language"`
+	DetectedSourceLanguage string `json:"detected_source_language"`
+}
+
+type RephraseOptions struct {
+	TargetLang   *string `json:"target_lang,omitempty"`
+	WritingStyle *string `json:"writing_style,omitempty"`
+	Tone         *string `json:"tone,omitempty"`
+
+	tenant string // tenant whose budget the request counts against
+}
+
+func (o *RephraseOptions) Gather(opts ...RephraseOption) error {
+	for _, option := range opts {
+		if err := option(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RephraseOption can be used to customize the rephrasing.
+type RephraseOption func(*RephraseOptions) error
+
+// WithRephraseTargetLang sets the language of the improved text. If this
+// parameter is omitted, the text is improved in its detected language, which
+// must be supported.
+//
+// Possible values are `de`, `en-GB`, `en-US`, `es`, `fr`, `it`, `ja`, `ko`,
+// `pt-BR`, `pt-PT` and `zh`. A text in another variant of the language, e.g.
+// British English with `en-US`, is converted.
+func WithRephraseTargetLang(value string) RephraseOption {
+	return func(o *RephraseOptions) error {
+		switch strings.ToLower(value) {
+		case "de", "en-gb", "en-us", "es", "fr", "it", "ja", "ko", "pt-br", "pt-pt", "zh":
+			o.TargetLang = &value
+			return nil
+		}
+		return translateOptionInvalidValueError("target_lang", value)
+	}
+}
+
+// WithWritingStyle sets the style the text is rewritten in. It cannot be
+// combined with WithTone.
+//
+// Possible values are:
+//   - `default`
+//   - `simple`, `business`, `academic` and `casual`
+//   - `prefer_simple`, `prefer_business`, `prefer_academic` and
+//     `prefer_casual` - use the style if the target language supports it,
+//     otherwise fallback to default
+func WithWritingStyle(value string) RephraseOption {
+	return func(o *RephraseOptions) error {
+		switch strings.TrimPrefix(value, "prefer_") {
+		case "default", "simple", "business", "academic", "casual":
+			if o.Tone != nil {
+				return fmt.Errorf("option `writing_style` cannot be combined with `tone`")
+			}
+			o.WritingStyle = &value
+			return nil
+		}
+		return translateOptionInvalidValueError("writing_style", value)
+	}
+}
+
+// WithTone sets the tone the text is rewritten in. It cannot be combined
+// with WithWritingStyle.
+//
+// Possible values are:
+//   - `default`
+//   - `enthusiastic`, `friendly`, `confident` and `diplomatic`
+//   - `prefer_enthusiastic`, `prefer_friendly`, `prefer_confident` and
+//     `prefer_diplomatic` - use the tone if the target language supports it,
+//     otherwise fallback to default
+func WithTone(value string) RephraseOption {
+	return func(o *RephraseOptions) error {
+		switch strings.TrimPrefix(value, "prefer_") {
+		case "default", "enthusiastic", "friendly", "confident", "diplomatic":
+			if o.WritingStyle != nil {
+				return fmt.Errorf("option `tone` cannot be combined with `writing_style`")
+			}
+			o.Tone = &value
+			return nil
+		}
+		return translateOptionInvalidValueError("tone", value)
+	}
+}
+
+// WithRephraseTenant sets the tenant whose budget the request counts
+// against, see WithBudget. The tenant is not sent to the API.
+func WithRephraseTenant(value string) RephraseOption {
+	return func(o *RephraseOptions) error {
+		o.tenant = value
+		return nil
+	}
+}
+
+// Rephrase improves the texts with the DeepL Write API, returning one
+// improvement per text.
+func (t *Translator) Rephrase(text []string, opts ...RephraseOption) (*RephraseResult, error) {
+	return t.RephraseContext(context.Background(), text, opts...)
+}
+
+// RephraseContext is like Rephrase but carries the supplied context through
+// to the underlying API request.
+//
+// With v1, i.e. a DeepLX server, the request is sent to the official v2
+// endpoint which DeepLX servers proxy next to their own v1 one.
+//
+// Like translation requests, identical concurrent requests are coalesced and
+// the characters sent are counted against the budget. Rephrasing is not a
+// translation, though, so middleware does not see the request and DryRun
+// does not cover it.
+func (t *Translator) RephraseContext(ctx context.Context, text []string, opts ...RephraseOption) (_ *RephraseResult, err error) {
+	var o RephraseOptions
+	if err := o.Gather(opts...); err != nil {
+		return nil, fmt.Errorf("error setting rephrase option: %w", err)
+	}
+	var targetLang string
+	if o.TargetLang != nil {
+		targetLang = *o.TargetLang
+	}
+
+	ctx, span := t.startSpan(ctx, SpanRephrase,
+		slog.String(TraceAttrTargetLang, targetLang), slog.Int(TraceAttrSegments, len(text)))
+	defer func() { span.End(err) }()
+
+	const endpoint = "write/rephrase"
+
+	data := struct {
+		Text []string `json:"text"`
+
+		RephraseOptions
+	}{
+		Text:            text,
+		RephraseOptions: o,
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request data: %w", err)
+	}
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+
+	characters := textCharacters(text)
+	ctx = withLogAttrs(ctx, slog.Int64("characters", characters))
+	ctx = withRequestInfo(ctx, requestInfo{targetLang: targetLang, characters: characters})
+	t.logText(ctx, text, targetLang)
+
+	resBody, err := t.post(ctx, VersionV2, endpoint, headers, body, o.tenant, characters)
+	if err != nil {
+		return nil, err
+	}
+
+	var result RephraseResult
+	if err := json.Unmarshal(resBody, &result); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	if len(result.Improvements) != len(text) {
+		return nil, fmt.Errorf("mismatched number of improvements, expected %d but got %d",
+			len(text), len(result.Improvements))
+	}
+	return &result, nil
+}
@@ -0,0 +1,111 @@
+package deeplx_translator
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRephrase(t *testing.T) {
+	for _, version := range []string{"/v1", "/v2"} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+version))
+
+		result, err := translator.Rephrase([]string{" I has a apple. ", "Thanks"},
+			WithRephraseTargetLang("en-US"), WithWritingStyle("prefer_business"))
+		if assert.NoError(t, err, version) && assert.Len(t, result.Improvements, 2) {
+			assert.Equal(t, Improvement{
+				Text:                   "I has a apple.",
+				TargetLanguage:         "en-US",
+				DetectedSourceLanguage: "en",
+			}, result.Improvements[0])
+		}
+		assert.Equal(t, map[string]any{
+			"text":          []any{" I has a apple. ", "Thanks"},
+			"target_lang":   "en-US",
+			"writing_style": "prefer_business",
+		}, server.lastRequest())
+	}
+}
+
+func TestRephraseOptions(t *testing.T) {
+	for _, opts := range [][]RephraseOption{
+		{WithRephraseTargetLang("EN-US"), WithTone("diplomatic")},
+		{WithWritingStyle("default")},
+		{WithTone("prefer_friendly")},
+	} {
+		var o RephraseOptions
+		assert.NoError(t, o.Gather(opts...))
+	}
+
+	for _, opts := range [][]RephraseOption{
+		{WithRephraseTargetLang("en")},
+		{WithWritingStyle("formal")},
+		{WithTone("prefer_angry")},
+		{WithWritingStyle("simple"), WithTone("friendly")},
+		{WithTone("friendly"), WithWritingStyle("simple")},
+	} {
+		var o RephraseOptions
+		assert.Error(t, o.Gather(opts...))
+	}
+
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+	_, err := translator.Rephrase([]string{"Hello"}, WithTone("rude"))
+	assert.Error(t, err)
+	assert.Zero(t, server.requests.Load())
+}
+
+func TestRephraseBudgetAndMiddleware(t *testing.T) {
+	server := newMockServer(t)
+	host, _ := url.Parse(server.URL)
+
+	var requests int
+	recorder := func(next TranslateHandler) TranslateHandler {
+		return func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			requests++
+			return next(ctx, req)
+		}
+	}
+	budget := NewBudget(WithDailyLimit(10))
+	metrics := NewMetrics()
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"),
+		WithBudget(budget), WithMetrics(metrics), WithMiddleware(recorder))
+
+	_, err := translator.Rephrase([]string{"Thanks"}, WithRephraseTenant("docs"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), budget.Used("docs", BudgetDaily))
+	assert.Zero(t, requests)
+
+	// The v2 endpoint is reported as such, next to the v1 base URL.
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, strings.ReplaceAll(string(body), host.Host, "HOST"),
+		`deeplx_requests_total{backend="HOST",endpoint="rephrase",target_lang="",status="200"} 1`+"\n")
+
+	_, err = translator.Rephrase([]string{"Hello, world!"}, WithRephraseTenant("docs"))
+	var budgetErr *BudgetExceededError
+	assert.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, int64(1), server.requests.Load())
+
+	// Failed requests give their characters back.
+	translator = NewTranslator("", WithBaseURL("http://127.0.0.1:0/v1"), WithBudget(budget))
+	_, err = translator.Rephrase([]string{"Hi"}, WithRephraseTenant("docs"))
+	assert.Error(t, err)
+	assert.Equal(t, int64(6), budget.Used("docs", BudgetDaily))
+}
+
+func TestVersionBaseURL(t *testing.T) {
+	translator := NewTranslator("", WithBaseURL("https://deeplx.example.com/v1"))
+	assert.Equal(t, "https://deeplx.example.com/v2", translator.versionBaseURL(translator.baseURL, VersionV2))
+	assert.Equal(t, "https://deeplx.example.com/v1", translator.versionBaseURL(translator.baseURL, VersionV1))
+
+	translator = NewTranslator("", WithBaseURL("https://api.example.com/v2"))
+	assert.Equal(t, "https://api.example.com/v2", translator.versionBaseURL(translator.baseURL, VersionV2))
+}
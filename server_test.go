@@ -0,0 +1,134 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+)
+
+// mockServer is a fake DeepL(X) backend that "translates" text by prefixing
+// it with the upper-cased target language, e.g. "[ZH]hello".
+type mockServer struct {
+	*httptest.Server
+
+	requests atomic.Int64
+
+	mu         sync.Mutex
+	last       map[string]any
+	lastHeader http.Header
+}
+
+// lastRequest returns the decoded JSON body of the most recent request.
+func (ms *mockServer) lastRequest() map[string]any {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.last
+}
+
+// lastRequestHeader returns the headers of the most recent request.
+func (ms *mockServer) lastRequestHeader() http.Header {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.lastHeader
+}
+
+func mockTranslate(text, targetLang string) string {
+	// Segments packed into a chunk are translated separately.
+	if chunkSegmentRegexp.MatchString(text) {
+		return chunkSegmentRegexp.ReplaceAllStringFunc(text, func(segment string) string {
+			inner := chunkSegmentRegexp.FindStringSubmatch(segment)[1]
+			return "<" + chunkTag + ">" + mockTranslate(inner, targetLang) + "</" + chunkTag + ">"
+		})
+	}
+	return "[" + strings.ToUpper(targetLang) + "]" + text
+}
+
+func newMockServer(t *testing.T) *mockServer {
+	t.Helper()
+
+	ms := &mockServer{}
+	ms.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms.requests.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+		var last map[string]any
+		_ = json.Unmarshal(body, &last)
+		ms.mu.Lock()
+		ms.last = last
+		ms.lastHeader = r.Header.Clone()
+		ms.mu.Unlock()
+
+		var req struct {
+			Text                 json.RawMessage `json:"text"`
+			TargetLang           string          `json:"target_lang"`
+			ModelType            string          `json:"model_type"`
+			ShowBilledCharacters bool            `json:"show_billed_characters"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/v2/write/rephrase"):
+			var texts []string
+			if err := json.Unmarshal(req.Text, &texts); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var result RephraseResult
+			for _, text := range texts {
+				result.Improvements = append(result.Improvements, Improvement{
+					Text:                   strings.TrimSpace(text),
+					TargetLanguage:         "en-US",
+					DetectedSourceLanguage: "en",
+				})
+			}
+			_ = json.NewEncoder(w).Encode(result)
+		case strings.HasSuffix(r.URL.Path, "/v2/translate"):
+			var texts []string
+			if err := json.Unmarshal(req.Text, &texts); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var result TranslationResultV2
+			for _, text := range texts {
+				tl := TranslationV2{
+					DetectedSourceLanguage: "EN",
+					Text:                   mockTranslate(text, req.TargetLang),
+				}
+				if req.ShowBilledCharacters {
+					tl.BilledCharacters = utf8.RuneCountInString(text)
+				}
+				if req.ModelType != "" {
+					tl.ModelTypeUsed = strings.TrimPrefix(req.ModelType, "prefer_")
+				}
+				result.Translations = append(result.Translations, tl)
+			}
+			_ = json.NewEncoder(w).Encode(result)
+		default:
+			var text string
+			if err := json.Unmarshal(req.Text, &text); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(TranslationResultV1{
+				Code:       http.StatusOK,
+				Data:       mockTranslate(text, req.TargetLang),
+				SourceLang: "EN",
+				TargetLang: req.TargetLang,
+			})
+		}
+	}))
+	t.Cleanup(ms.Close)
+
+	return ms
+}
@@ -0,0 +1,416 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type SubtitleFormat uint8
+
+const (
+	SubtitleFormatSRT SubtitleFormat = iota + 1
+	SubtitleFormatWebVTT
+)
+
+// maxSubtitleGroupSize is the maximum number of consecutive cues translated
+// together as one sentence.
+const maxSubtitleGroupSize = 4
+
+// subtitleTagPattern matches styling tags, e.g. <i>, <font color="red">,
+// <c.yellow>, <v Bob>, <00:01.500> and {\an8}.
+const subtitleTagPattern = `(?:<[^<>\n]+>|\{\\[^{}\n]*\})`
+
+var (
+	subtitleTagRegexp       = regexp.MustCompile(`^` + subtitleTagPattern)
+	subtitleTagRegexpGlobal = regexp.MustCompile(subtitleTagPattern)
+)
+
+// Subtitles is a parsed SRT or WebVTT file. Apart from the cue text, the
+// file is written back exactly as it was read.
+type Subtitles struct {
+	Format SubtitleFormat
+	Cues   []*SubtitleCue
+
+	parts []subtitlePart
+}
+
+// SubtitleCue is a single cue of a subtitle file.
+type SubtitleCue struct {
+	// Lines holds the text lines of the cue, without line endings.
+	Lines []string
+
+	header  string // raw identifier and timing lines
+	eol     string // line ending of all but the last text line
+	lastEOL string // line ending of the last text line
+}
+
+type subtitlePart struct {
+	text string
+	cue  *SubtitleCue // nil for literal text
+}
+
+// ParseSubtitles reads an SRT or WebVTT file. WebVTT is detected by its
+// "WEBVTT" signature, everything else is parsed as SRT.
+func ParseSubtitles(r io.Reader) (*Subtitles, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading subtitles: %w", err)
+	}
+
+	var (
+		s     = &Subtitles{Format: SubtitleFormatSRT}
+		lines = strings.SplitAfter(string(data), "\n")
+	)
+	if strings.HasPrefix(strings.TrimPrefix(lines[0], "\uFEFF"), "WEBVTT") {
+		s.Format = SubtitleFormatWebVTT
+	}
+
+	for i := 0; i < len(lines); {
+		if isBlankLine(lines[i]) {
+			s.literal(lines[i])
+			i++
+			continue
+		}
+
+		// Collect the lines of a block up to the next blank line.
+		j := i
+		for j < len(lines) && !isBlankLine(lines[j]) {
+			j++
+		}
+		block := lines[i:j]
+		i = j
+
+		timing := slices.IndexFunc(block, func(line string) bool {
+			return strings.Contains(line, "-->")
+		})
+		if timing < 0 || timing > 1 {
+			// Header, NOTE, STYLE and REGION blocks, or anything unknown.
+			s.literal(strings.Join(block, ""))
+			continue
+		}
+
+		cue := &SubtitleCue{
+			header: strings.Join(block[:timing+1], ""),
+			eol:    lineEnding(block[timing]),
+		}
+		cue.lastEOL = cue.eol
+		for _, line := range block[timing+1:] {
+			cue.Lines = append(cue.Lines, strings.TrimRight(line, "\r\n"))
+			cue.lastEOL = lineEnding(line)
+		}
+		s.Cues = append(s.Cues, cue)
+		s.parts = append(s.parts, subtitlePart{cue: cue})
+	}
+	return s, nil
+}
+
+func (s *Subtitles) literal(text string) {
+	s.parts = append(s.parts, subtitlePart{text: text})
+}
+
+// WriteTo writes the subtitles in their original format.
+func (s *Subtitles) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for _, part := range s.parts {
+		if part.cue == nil {
+			sb.WriteString(part.text)
+			continue
+		}
+		sb.WriteString(part.cue.header)
+		for i, line := range part.cue.Lines {
+			sb.WriteString(line)
+			if i < len(part.cue.Lines)-1 {
+				sb.WriteString(part.cue.eol)
+			} else {
+				sb.WriteString(part.cue.lastEOL)
+			}
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// TranslateSubtitles translates the text of all cues in place, keeping the
+// number of lines of each cue.
+//
+// Consecutive cues that continue a sentence are translated together, up to
+// maxSubtitleGroupSize cues at a time, and the translation is distributed
+// back over the cues and their lines. Cues with dialogue lines starting with
+// a dash are translated line by line. Styling tags are protected from the
+// translation engine, and closed and opened again where a sentence is split
+// between cues.
+func (t *Translator) TranslateSubtitles(ctx context.Context, subtitles *Subtitles, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	groups := groupSubtitleCues(subtitles.Cues)
+
+	segments := make([]string, len(groups))
+	tokens := make([][]string, len(groups))
+	for i, group := range groups {
+		segments[i], tokens[i] = protectTokens(group.text(), true, subtitleTagLen)
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(protectOptions(o, true)))
+	if err != nil {
+		return err
+	}
+	for i, group := range groups {
+		result, err := restorePlaceholders(results[i], tokens[i], true)
+		if err != nil {
+			return err
+		}
+		group.apply(result)
+	}
+	return nil
+}
+
+// subtitleGroup is a run of text translated as one segment, either a number
+// of whole cues or a single dialogue line.
+type subtitleGroup struct {
+	cues []*SubtitleCue
+	line int // index of the dialogue line, or -1 for whole cues
+}
+
+func (g *subtitleGroup) text() string {
+	if g.line >= 0 {
+		return g.cues[0].Lines[g.line]
+	}
+	texts := make([]string, len(g.cues))
+	for i, cue := range g.cues {
+		texts[i] = strings.Join(cue.Lines, " ")
+	}
+	return strings.Join(texts, " ")
+}
+
+// apply distributes the translated text of the group back to its cues.
+// Styling tags left open at the end of a cue are closed there and opened
+// again in the next one.
+func (g *subtitleGroup) apply(text string) {
+	if g.line >= 0 {
+		g.cues[0].Lines[g.line] = text
+		return
+	}
+
+	weights := make([]int, len(g.cues))
+	for i, cue := range g.cues {
+		weights[i] = utf8.RuneCountInString(strings.Join(cue.Lines, " "))
+	}
+	for i, part := range balanceSubtitleTags(splitProportionally(text, weights)) {
+		cue := g.cues[i]
+		lineWeights := make([]int, len(cue.Lines))
+		for j, line := range cue.Lines {
+			lineWeights[j] = utf8.RuneCountInString(line)
+		}
+		lines := splitProportionally(part, lineWeights)
+		for j := range lines {
+			// An empty line would end the cue, which only happens if the
+			// translation is too short to go round.
+			if strings.TrimSpace(subtitleTagRegexpGlobal.ReplaceAllString(lines[j], "")) == "" {
+				lines[j] += "…"
+			}
+		}
+		cue.Lines = lines
+	}
+}
+
+func groupSubtitleCues(cues []*SubtitleCue) []*subtitleGroup {
+	var (
+		groups  []*subtitleGroup
+		current *subtitleGroup
+	)
+	for _, cue := range cues {
+		if strings.TrimSpace(strings.Join(cue.Lines, "")) == "" {
+			current = nil
+			continue
+		}
+
+		if isSubtitleDialogue(cue) {
+			current = nil
+			for i := range cue.Lines {
+				groups = append(groups, &subtitleGroup{cues: []*SubtitleCue{cue}, line: i})
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &subtitleGroup{line: -1}
+			groups = append(groups, current)
+		}
+		current.cues = append(current.cues, cue)
+
+		last := strings.TrimSpace(subtitleTagRegexpGlobal.ReplaceAllString(cue.Lines[len(cue.Lines)-1], ""))
+		if len(current.cues) >= maxSubtitleGroupSize || endsWithTerminator(last) {
+			current = nil
+		}
+	}
+	return groups
+}
+
+func isSubtitleDialogue(cue *SubtitleCue) bool {
+	if len(cue.Lines) < 2 {
+		return false
+	}
+	for _, line := range cue.Lines[1:] {
+		if strings.HasPrefix(subtitleTagRegexpGlobal.ReplaceAllString(line, ""), "-") {
+			return true
+		}
+	}
+	return false
+}
+
+func endsWithTerminator(text string) bool {
+	for _, term := range defaultSentenceTerminators {
+		if term != "\n" && strings.HasSuffix(text, term) {
+			return true
+		}
+	}
+	return strings.HasSuffix(text, "…")
+}
+
+func subtitleTagLen(s string) int {
+	return len(subtitleTagRegexp.FindString(s))
+}
+
+// splitProportionally splits text into len(weights) parts whose lengths are
+// roughly proportional to weights. Text is split at spaces, or between any
+// two characters for scripts written without spaces or when there are not
+// enough spaces, but never within a styling tag. Every part has text unless
+// text has fewer characters than there are weights.
+func splitProportionally(text string, weights []int) []string {
+	if len(weights) <= 1 {
+		return []string{text}
+	}
+
+	breaks, positions, runes := subtitleBreaks(text, true)
+	atSpace := len(breaks) >= len(weights)-1 &&
+		(strings.Contains(text, " ") || !strings.ContainsFunc(text, isUnspacedScript))
+	if !atSpace {
+		breaks, positions, runes = subtitleBreaks(text, false)
+	}
+
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	parts := make([]string, 0, len(weights))
+	start, next, cumulative := 0, 0, 0
+	for n, w := range weights[:len(weights)-1] {
+		cumulative += w
+		target := runes * cumulative / max(total, 1)
+
+		// Pick the candidate nearest to the target after the previous one,
+		// leaving one for each of the following parts.
+		best := -1
+		last := min(max(len(breaks)-(len(weights)-2-n), next+1), len(breaks))
+		for k := next; k < last; k++ {
+			if breaks[k] <= start {
+				continue
+			}
+			if best < 0 || abs(positions[k]-target) < abs(positions[best]-target) {
+				best = k
+			}
+		}
+		if best < 0 {
+			// Out of places to split, so keep the rest together.
+			parts = append(parts, text[start:])
+			start = len(text)
+			continue
+		}
+		part := text[start:breaks[best]]
+		start = breaks[best]
+		if atSpace {
+			start++
+		} else {
+			part = strings.TrimRight(part, " ")
+		}
+		parts = append(parts, part)
+		next = best + 1
+	}
+	return append(parts, text[start:])
+}
+
+// subtitleBreaks returns the byte offsets at which text may be split, either
+// at spaces or between any two characters, along with their rune offsets and
+// the number of runes of text. Styling tags are never split.
+func subtitleBreaks(text string, atSpace bool) (breaks, positions []int, runes int) {
+	for i := 0; i < len(text); {
+		if n := subtitleTagLen(text[i:]); n > 0 {
+			i += n
+			runes += utf8.RuneCountInString(text[i-n : i])
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if (atSpace && r == ' ') || (!atSpace && i > 0 && r != ' ') {
+			breaks = append(breaks, i)
+			positions = append(positions, runes)
+		}
+		i += size
+		runes++
+	}
+	return breaks, positions, runes
+}
+
+// subtitleStyleTagRegexp matches opening and closing styling tags with a
+// name, e.g. <i>, </i>, <font color="red"> or <c.yellow>, but not {\an8} or
+// timestamps.
+var subtitleStyleTagRegexp = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)[^<>\n]*>`)
+
+// balanceSubtitleTags closes the styling tags left open at the end of each
+// part and opens them again at the start of the following one.
+func balanceSubtitleTags(parts []string) []string {
+	var open []string // raw opening tags
+	for i, part := range parts {
+		reopen := strings.Join(open, "")
+		for _, m := range subtitleStyleTagRegexp.FindAllStringSubmatch(part, -1) {
+			if m[1] == "" {
+				open = append(open, m[0])
+				continue
+			}
+			for j := len(open) - 1; j >= 0; j-- {
+				if subtitleStyleTagRegexp.FindStringSubmatch(open[j])[2] == m[2] {
+					open = slices.Delete(open, j, j+1)
+					break
+				}
+			}
+		}
+		var closing strings.Builder
+		if i < len(parts)-1 {
+			for j := len(open) - 1; j >= 0; j-- {
+				closing.WriteString("</" + subtitleStyleTagRegexp.FindStringSubmatch(open[j])[2] + ">")
+			}
+		}
+		parts[i] = reopen + part + closing.String()
+	}
+	return parts
+}
+
+// isUnspacedScript reports whether r belongs to a script that is written
+// without spaces between words.
+func isUnspacedScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai)
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimRight(line, "\r\n") == ""
+}
+
+func lineEnding(line string) string {
+	return line[len(strings.TrimRight(line, "\r\n")):]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
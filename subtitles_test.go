@@ -0,0 +1,181 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const srtSample = "1\r\n" +
+	"00:00:01,000 --> 00:00:02,500\r\n" +
+	"<i>Where are</i>\r\n" +
+	"you going\r\n" +
+	"\r\n" +
+	"2\r\n" +
+	"00:00:02,600 --> 00:00:04,000\r\n" +
+	"tonight?\r\n" +
+	"\r\n" +
+	"3\r\n" +
+	"00:00:04,100 --> 00:00:06,000\r\n" +
+	"- Home.\r\n" +
+	"- {\\an8}Why?\r\n"
+
+const vttSample = "WEBVTT - Sample\n" +
+	"\n" +
+	"NOTE This is a comment\n" +
+	"\n" +
+	"STYLE\n" +
+	"::cue { color: yellow; }\n" +
+	"\n" +
+	"intro\n" +
+	"00:01.000 --> 00:04.000 line:0\n" +
+	"<v Bob>Hello, world!</v>\n" +
+	"\n" +
+	"00:05.000 --> 00:06.000\n" +
+	"Bye."
+
+func TestParseSubtitles(t *testing.T) {
+	for _, test := range []struct {
+		input  string
+		format SubtitleFormat
+		cues   [][]string
+	}{
+		{srtSample, SubtitleFormatSRT, [][]string{
+			{"<i>Where are</i>", "you going"},
+			{"tonight?"},
+			{"- Home.", "- {\\an8}Why?"},
+		}},
+		{vttSample, SubtitleFormatWebVTT, [][]string{
+			{"<v Bob>Hello, world!</v>"},
+			{"Bye."},
+		}},
+	} {
+		subtitles, err := ParseSubtitles(strings.NewReader(test.input))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, test.format, subtitles.Format)
+		var cues [][]string
+		for _, cue := range subtitles.Cues {
+			cues = append(cues, cue.Lines)
+		}
+		assert.Equal(t, test.cues, cues)
+
+		buf := &bytes.Buffer{}
+		_, err = subtitles.WriteTo(buf)
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.input, buf.String())
+		}
+	}
+}
+
+func TestTranslateSubtitles(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	subtitles, err := ParseSubtitles(strings.NewReader(srtSample))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.NoError(t, translator.TranslateSubtitles(context.Background(), subtitles, "de")) {
+		buf := &bytes.Buffer{}
+		_, _ = subtitles.WriteTo(buf)
+		assert.Equal(t, "1\r\n"+
+			"00:00:01,000 --> 00:00:02,500\r\n"+
+			"[DE]<i>Where are</i>\r\n"+
+			"you going\r\n"+
+			"\r\n"+
+			"2\r\n"+
+			"00:00:02,600 --> 00:00:04,000\r\n"+
+			"tonight?\r\n"+
+			"\r\n"+
+			"3\r\n"+
+			"00:00:04,100 --> 00:00:06,000\r\n"+
+			"[DE]- Home.\r\n"+
+			"[DE]- {\\an8}Why?\r\n", buf.String())
+	}
+	// One group for the first two cues, and one per dialogue line.
+	assert.Len(t, server.lastRequest()["text"], 3)
+}
+
+func TestSplitProportionally(t *testing.T) {
+	tests := []struct {
+		text     string
+		weights  []int
+		expected []string
+	}{
+		{"one two three four", []int{1}, []string{"one two three four"}},
+		{"one two three four", []int{7, 10}, []string{"one two", "three four"}},
+		{"<i>one two</i> three", []int{3, 10}, []string{"<i>one", "two</i> three"}},
+		{"你好世界", []int{1, 1}, []string{"你好", "世界"}},
+		{"word", []int{1, 1}, []string{"wo", "rd"}},
+		{"one two three four", []int{1, 1, 100}, []string{"one", "two", "three four"}},
+		{"one two", []int{1, 1, 1}, []string{"on", "e", "two"}},
+		{"ab", []int{1, 1, 1}, []string{"a", "b", ""}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, splitProportionally(tt.text, tt.weights))
+	}
+}
+
+func TestBalanceSubtitleTags(t *testing.T) {
+	tests := []struct {
+		parts    []string
+		expected []string
+	}{
+		{[]string{"one", "two"}, []string{"one", "two"}},
+		{
+			[]string{"<i>Hello there", "friend</i> and <b>you"},
+			[]string{"<i>Hello there</i>", "<i>friend</i> and <b>you"},
+		},
+		{
+			[]string{`<font color="red"><i>a`, "b</i>", "c</font> {\\an8}d"},
+			[]string{`<font color="red"><i>a</i></font>`, `<font color="red"><i>b</i></font>`, `<font color="red">c</font> {\an8}d`},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, balanceSubtitleTags(tt.parts))
+	}
+}
+
+func TestSubtitleGroupApply(t *testing.T) {
+	tests := []struct {
+		lines    [][]string
+		text     string
+		expected [][]string
+	}{
+		{
+			// Styling tags are balanced within each cue.
+			[][]string{{"<i>It was a dark"}, {"and stormy night.</i>"}},
+			"<i>Es war eine dunkle und stürmische Nacht.</i>",
+			[][]string{{"<i>Es war eine dunkle</i>"}, {"<i>und stürmische Nacht.</i>"}},
+		},
+		{
+			// Every cue and line keeps text, even with a short translation.
+			[][]string{{"Well,", "well,"}, {"well."}},
+			"Na ja.",
+			[][]string{{"N", "a"}, {"ja."}},
+		},
+		{
+			[][]string{{"A", "B"}, {"C"}},
+			"ab",
+			[][]string{{"a", "…"}, {"b"}},
+		},
+	}
+	for _, tt := range tests {
+		group := &subtitleGroup{line: -1}
+		for _, lines := range tt.lines {
+			group.cues = append(group.cues, &SubtitleCue{Lines: lines})
+		}
+		group.apply(tt.text)
+
+		var actual [][]string
+		for _, cue := range group.cues {
+			actual = append(actual, cue.Lines)
+		}
+		assert.Equal(t, tt.expected, actual)
+	}
+}
@@ -0,0 +1,107 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// Tracer starts spans for tracing translations end-to-end. Its shape follows
+// OpenTelemetry, for which the deeplxotel package provides an adapter.
+type Tracer interface {
+	// Start starts a span, which is a child of the span in ctx, if any, and
+	// returns a context carrying it.
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	// End ends the span, which failed with err unless it is nil.
+	End(err error)
+}
+
+// Names of the spans of a Translator. Only SpanHTTP stands for a call to a
+// remote service, i.e. is a client span in OpenTelemetry terms.
+const (
+	SpanTranslateText = "deeplx.TranslateText"
+	SpanRephrase      = "deeplx.Rephrase"
+	SpanTranslate     = "deeplx.translate"
+	SpanHTTP          = "deeplx.http"
+)
+
+// Attribute keys of the spans of a Translator.
+const (
+	TraceAttrVersion    = "deeplx.version"
+	TraceAttrTargetLang = "deeplx.target_lang"
+	TraceAttrSegments   = "deeplx.segments"
+	TraceAttrHost       = "server.address"
+	TraceAttrMethod     = "http.request.method"
+	TraceAttrEndpoint   = "url.path"
+	TraceAttrStatusCode = "http.response.status_code"
+)
+
+// WithTracer makes the Translator trace its work with tracer: a span
+// SpanTranslateText per TranslateText call, with a child span SpanTranslate
+// per translation request, i.e. per batch, which itself has a child span
+// SpanHTTP per HTTP request made for it.
+func WithTracer(tracer Tracer) TranslatorOption {
+	return func(t *Translator) {
+		t.tracer = tracer
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+
+func (noopSpan) End(error) {}
+
+// startSpan starts a span with the attributes common to all spans of the
+// Translator, if it has a tracer.
+func (t *Translator) startSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, noopSpan{}
+	}
+
+	common := []slog.Attr{slog.String(TraceAttrVersion, fmt.Sprintf("v%d", t.version))}
+	if u, err := url.Parse(t.baseURL); err == nil {
+		common = append(common, slog.String(TraceAttrHost, u.Host))
+	}
+	return t.tracer.Start(ctx, name, append(common, attrs...)...)
+}
+
+// startHTTPSpan starts the span of a single HTTP request to the API.
+func (t *Translator) startHTTPSpan(ctx context.Context, req *http.Request) (context.Context, Span) {
+	ctx, span := t.startSpan(ctx, SpanHTTP,
+		slog.String(TraceAttrMethod, req.Method),
+		slog.String(TraceAttrEndpoint, req.URL.Path))
+	// The key pool may send the request elsewhere than the base URL.
+	span.SetAttributes(slog.String(TraceAttrHost, req.URL.Host))
+	return ctx, span
+}
+
+// endHTTPSpan ends the span of an HTTP request with its outcome.
+func endHTTPSpan(span Span, res *http.Response, err error) {
+	if res != nil {
+		span.SetAttributes(slog.Int(TraceAttrStatusCode, res.StatusCode))
+		if err == nil && res.StatusCode >= http.StatusBadRequest {
+			err = httpError(res.StatusCode)
+		}
+	}
+	span.End(err)
+}
+
+// textSegments returns the number of segments of text as sent to the API.
+func textSegments(text any) int {
+	switch v := text.(type) {
+	case string:
+		return 1
+	case []string:
+		return len(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,107 @@
+package deeplx_translator
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value.Any()
+	}
+}
+
+func (s *recordedSpan) End(err error) {
+	s.err, s.ended = err, true
+}
+
+type spanKey struct{}
+
+// recordingTracer records the spans it starts.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tr *recordingTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: make(map[string]any)}
+	span.SetAttributes(attrs...)
+
+	tr.mu.Lock()
+	tr.spans = append(tr.spans, span)
+	tr.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	server := newMockServer(t)
+	host, _ := url.Parse(server.URL)
+
+	tracer := &recordingTracer{}
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithTracer(tracer))
+	_, err := translator.TranslateText([]string{"Hello", "world", "Hello"}, "DE")
+	assert.NoError(t, err)
+
+	if assert.Len(t, tracer.spans, 3) {
+		root, batch, call := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+
+		assert.Equal(t, "deeplx.TranslateText", root.name)
+		assert.Nil(t, root.parent)
+		assert.Equal(t, map[string]any{
+			TraceAttrVersion:    "v2",
+			TraceAttrHost:       host.Host,
+			TraceAttrTargetLang: "DE",
+			TraceAttrSegments:   int64(3),
+		}, root.attrs)
+
+		assert.Equal(t, "deeplx.translate", batch.name)
+		assert.Same(t, root, batch.parent)
+		assert.Equal(t, int64(2), batch.attrs[TraceAttrSegments])
+
+		assert.Equal(t, "deeplx.http", call.name)
+		assert.Same(t, batch, call.parent)
+		assert.Equal(t, "POST", call.attrs[TraceAttrMethod])
+		assert.Equal(t, "/v2/translate", call.attrs[TraceAttrEndpoint])
+		assert.Equal(t, int64(200), call.attrs[TraceAttrStatusCode])
+
+		for _, span := range tracer.spans {
+			assert.True(t, span.ended)
+			assert.NoError(t, span.err)
+		}
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithTracer(tracer))
+	_, err := translator.TranslateText([]string{"Hello"}, "DE")
+	assert.Error(t, err)
+
+	if assert.Len(t, tracer.spans, 3) {
+		for _, span := range tracer.spans {
+			assert.EqualError(t, span.err, "503 - Service Unavailable")
+		}
+		assert.Equal(t, int64(503), tracer.spans[2].attrs[TraceAttrStatusCode])
+	}
+}
@@ -2,10 +2,15 @@ package deeplx_translator
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type TranslationResultV1 struct {
@@ -20,20 +25,47 @@ type TranslationResultV1 struct {
 }
 
 type TranslationResultV2 struct {
-	Translations []struct {
-		DetectedSourceLanguage string `json:"detected_source_language"`
-		Text                   string `json:"text"`
-	} `json:"translations"`
+	Translations []TranslationV2 `json:"translations"`
+
+	// CharactersSaved counts the characters of duplicate texts that were
+	// not sent, since identical texts are only translated once.
+	CharactersSaved int `json:"-"`
+}
+
+// TranslationV2 is the translation of a single text with v2.
+type TranslationV2 struct {
+	DetectedSourceLanguage string `json:"detected_source_language"`
+	Text                   string `json:"text"`
+
+	// BilledCharacters is only returned with WithShowBilledCharacters. The
+	// characters of a duplicate text are billed to its first occurrence.
+	BilledCharacters int `json:"billed_characters,omitempty"`
+	// ModelTypeUsed is only returned with WithModelType.
+	ModelTypeUsed string `json:"model_type_used,omitempty"`
 }
 
 func (t *Translator) TranslateText(text any, targetLang string, opts ...TranslateOption) (string, error) {
+	return t.TranslateTextContext(context.Background(), text, targetLang, opts...)
+}
+
+// TranslateTextContext is like TranslateText but carries the supplied context
+// through to the underlying API request.
+func (t *Translator) TranslateTextContext(ctx context.Context, text any, targetLang string, opts ...TranslateOption) (_ string, err error) {
+	ctx, span := t.startSpan(ctx, SpanTranslateText,
+		slog.String(TraceAttrTargetLang, targetLang), slog.Int(TraceAttrSegments, textSegments(text)))
+	defer func() { span.End(err) }()
+
+	if t.memory != nil || (t.version == VersionV2 && hasAutoContext(opts)) {
+		return t.translateTextSegmented(ctx, text, targetLang, opts...)
+	}
+
 	switch t.version {
 	case VersionV1:
 		v, err := textToString(text)
 		if err != nil {
 			return "", err
 		}
-		resp, err := t.TranslateTextV1(v, targetLang, opts...)
+		resp, err := t.translateTextV1(ctx, v, targetLang, opts...)
 		if err != nil {
 			return "", err
 		}
@@ -43,7 +75,7 @@ func (t *Translator) TranslateText(text any, targetLang string, opts ...Translat
 		if err != nil {
 			return "", err
 		}
-		resp, err := t.TranslateTextV2(v, targetLang, opts...)
+		resp, err := t.translateTextV2(ctx, v, targetLang, opts...)
 		if err != nil {
 			return "", err
 		}
@@ -57,11 +89,44 @@ func (t *Translator) TranslateText(text any, targetLang string, opts ...Translat
 	}
 }
 
+// translateTextSegmented translates text like TranslateTextContext, but
+// segment by segment, so that only the segments that are not found in the
+// translation memory are sent, and batches are sent with context.
+func (t *Translator) translateTextSegmented(ctx context.Context, text any, targetLang string, opts ...TranslateOption) (string, error) {
+	var segments []string
+	switch t.version {
+	case VersionV1:
+		v, err := textToString(text)
+		if err != nil {
+			return "", err
+		}
+		segments = []string{v}
+	case VersionV2:
+		v, err := textToStringSlice(text)
+		if err != nil {
+			return "", err
+		}
+		segments = v
+	default:
+		return "", fmt.Errorf("invalid API version: %d", t.version)
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, opts...)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(results, ""), nil
+}
+
 func (t *Translator) TranslateTextV1(text string, targetLang string, opts ...TranslateOption) (*TranslationResultV1, error) {
+	return t.translateTextV1(context.Background(), text, targetLang, opts...)
+}
+
+func (t *Translator) translateTextV1(ctx context.Context, text string, targetLang string, opts ...TranslateOption) (*TranslationResultV1, error) {
 	if t.version != VersionV1 {
 		return nil, fmt.Errorf("mismatched API version, expected v1 but got v%d", t.version)
 	}
-	resp, err := t.translateRequest(text, targetLang, opts...)
+	resp, err := t.translateRequest(ctx, text, targetLang, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,24 +137,103 @@ func (t *Translator) TranslateTextV1(text string, targetLang string, opts ...Tra
 }
 
 func (t *Translator) TranslateTextV2(text []string, targetLang string, opts ...TranslateOption) (*TranslationResultV2, error) {
+	return t.translateTextV2(context.Background(), text, targetLang, opts...)
+}
+
+func (t *Translator) translateTextV2(ctx context.Context, text []string, targetLang string, opts ...TranslateOption) (*TranslationResultV2, error) {
 	if t.version != VersionV2 {
 		return nil, fmt.Errorf("mismatched API version, expected v2 but got v%d", t.version)
 	}
-	resp, err := t.translateRequest(text, targetLang, opts...)
+
+	// Send identical texts only once and fan the translations back out.
+	var (
+		unique    = make([]string, 0, len(text))
+		positions = make([]int, len(text))
+		seen      = make(map[string]int)
+		saved     int
+	)
+	for i, v := range text {
+		j, ok := seen[v]
+		if !ok {
+			j = len(unique)
+			seen[v] = j
+			unique = append(unique, v)
+		} else {
+			saved += utf8.RuneCountInString(v)
+		}
+		positions[i] = j
+	}
+
+	resp, err := t.translateRequest(ctx, unique, targetLang, opts...)
 	if err != nil {
 		return nil, err
 	}
-	if result, ok := resp.(*TranslationResultV2); ok {
-		return result, nil
+	result, ok := resp.(*TranslationResultV2)
+	if !ok {
+		return nil, fmt.Errorf("invalid response type: %T", resp)
 	}
-	return nil, fmt.Errorf("invalid response type: %T", resp)
+	if saved > 0 {
+		if len(result.Translations) != len(unique) {
+			return nil, fmt.Errorf("mismatched number of translations, expected %d but got %d",
+				len(unique), len(result.Translations))
+		}
+		translations := make([]TranslationV2, 0, len(positions))
+		billed := make([]bool, len(unique))
+		for _, j := range positions {
+			tl := result.Translations[j]
+			if billed[j] {
+				tl.BilledCharacters = 0
+			}
+			billed[j] = true
+			translations = append(translations, tl)
+		}
+		result.Translations = translations
+		result.CharactersSaved = saved
+	}
+	return result, nil
 }
 
-func (t *Translator) translateRequest(text any, targetLang string, opts ...TranslateOption) (any, error) {
-	const (
-		endpoint = "translate"
-		method   = http.MethodPost
-	)
+func (t *Translator) translateRequest(ctx context.Context, text any, targetLang string, opts ...TranslateOption) (_ any, err error) {
+	ctx, span := t.startSpan(ctx, SpanTranslate,
+		slog.String(TraceAttrTargetLang, targetLang), slog.Int(TraceAttrSegments, textSegments(text)))
+	defer func() { span.End(err) }()
+
+	req := &TranslateRequest{
+		Text:       text,
+		TargetLang: targetLang,
+		Version:    t.version,
+		Header:     make(http.Header),
+	}
+	if err := req.Options.Gather(opts...); err != nil {
+		return nil, fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	// Dry runs stop short of middleware, which may have side effects.
+	if dr, ok := ctx.Value(dryRunKey{}).(*dryRun); ok {
+		dr.add(textCharacters(text))
+		return dr.response(t.version, text, targetLang), nil
+	}
+
+	resp, err := t.translateHandler()(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("middleware returned no response")
+	}
+	return resp.Result, nil
+}
+
+// sendTranslateRequest is the innermost TranslateHandler, which sends the
+// request to the API.
+func (t *Translator) sendTranslateRequest(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+	start := time.Now()
+	result, err := t.doTranslateRequest(ctx, req)
+	return &TranslateResponse{Result: result, Duration: time.Since(start)}, err
+}
+
+func (t *Translator) doTranslateRequest(ctx context.Context, req *TranslateRequest) (any, error) {
+	const endpoint = "translate"
 
 	data := struct {
 		Text       any    `json:"text"`
@@ -97,15 +241,16 @@ func (t *Translator) translateRequest(text any, targetLang string, opts ...Trans
 
 		TranslateOptions
 	}{
-		Text:       text,
-		TargetLang: targetLang,
-	}
-	if err := data.Gather(opts...); err != nil {
-		return nil, fmt.Errorf("error setting translate option: %w", err)
+		Text:             req.Text,
+		TargetLang:       req.TargetLang,
+		TranslateOptions: req.Options,
 	}
 
 	// Setup request
-	headers := make(http.Header)
+	headers := req.Header.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
 	headers.Set("Content-Type", "application/json")
 
 	body, err := json.Marshal(data)
@@ -113,16 +258,15 @@ func (t *Translator) translateRequest(text any, targetLang string, opts ...Trans
 		return nil, fmt.Errorf("error encoding request data: %w", err)
 	}
 
-	// Send request
-	res, err := t.callAPI(method, endpoint, headers, bytes.NewReader(body))
+	characters := textCharacters(req.Text)
+	ctx = withLogAttrs(ctx, slog.Int64("characters", characters))
+	ctx = withRequestInfo(ctx, requestInfo{targetLang: req.TargetLang, characters: characters})
+	t.logText(ctx, req.Text, req.TargetLang)
+
+	resBody, err := t.post(ctx, t.version, endpoint, headers, body, data.tenant, characters)
 	if err != nil {
 		return nil, err
 	}
-	//nolint:errcheck
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, httpError(res.StatusCode)
-	}
 
 	// Parse response
 	var response = map[Version]any{
@@ -130,9 +274,51 @@ func (t *Translator) translateRequest(text any, targetLang string, opts ...Trans
 		VersionV2: &TranslationResultV2{},
 	}[t.version]
 
-	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+	if err := json.Unmarshal(resBody, response); err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
+
+// post sends body to the endpoint of the given API version and returns the
+// response body. The response is shared with identical concurrent requests
+// of the same tenant, and characters are counted against its budget unless
+// the request fails.
+func (t *Translator) post(ctx context.Context, version Version, endpoint string, headers http.Header, body []byte, tenant string, characters int64) ([]byte, error) {
+	key := fmt.Sprintf("v%d %s %q %s %v", version, endpoint, tenant, body, headers)
+	resBody, shared, err := t.requests.do(ctx, key, func(ctx context.Context) ([]byte, error) {
+		var reserved time.Time
+		if t.budget != nil {
+			var err error
+			if reserved, err = t.budget.reserve(tenant, characters); err != nil {
+				return nil, err
+			}
+		}
+		res, err := t.callAPI(ctx, version, http.MethodPost, endpoint, headers, bytes.NewReader(body))
+		if err == nil && res.StatusCode != http.StatusOK {
+			//nolint:errcheck
+			res.Body.Close()
+			err = httpError(res.StatusCode)
+		}
+		var resBody []byte
+		if err == nil {
+			//nolint:errcheck
+			defer res.Body.Close()
+			if resBody, err = io.ReadAll(res.Body); err != nil {
+				err = fmt.Errorf("error reading response: %w", err)
+			}
+		}
+		if err != nil {
+			if t.budget != nil {
+				t.budget.release(tenant, characters, reserved)
+			}
+			return nil, err
+		}
+		return resBody, nil
+	})
+	if t.metrics != nil {
+		t.metrics.ObserveCache("coalesced", shared)
+	}
+	return resBody, err
+}
@@ -0,0 +1,284 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxBatchSize is the maximum number of texts allowed in a single v2 request.
+const maxBatchSize = 50
+
+// translateSegments translates each of the segments independently and
+// returns the translations in the same order. Blank segments are returned
+// as is and segments found in the translation memory, if any, are taken
+// from there; neither is sent.
+//
+// With v2, segments are sent in batches of up to maxBatchSize texts. With v1,
+// which only accepts a single text, segments are sent one per request with at
+// most WithMaxConcurrency requests in flight.
+func (t *Translator) translateSegments(ctx context.Context, segments []string, targetLang string, opts ...TranslateOption) ([]string, error) {
+	results, _, err := t.translateSegmentsBilled(ctx, segments, targetLang, opts...)
+	return results, err
+}
+
+// translateSegmentsBilled is like translateSegments, but also returns the
+// number of characters sent, which is what the API bills.
+func (t *Translator) translateSegmentsBilled(ctx context.Context, segments []string, targetLang string, opts ...TranslateOption) ([]string, int, error) {
+	results := make([]string, len(segments))
+
+	var pending []int
+	for i, segment := range segments {
+		if strings.TrimSpace(segment) == "" {
+			results[i] = segment
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	if t.memory != nil {
+		var o TranslateOptions
+		if err := o.Gather(opts...); err != nil {
+			return nil, 0, fmt.Errorf("error setting translate option: %w", err)
+		}
+		var sourceLang string
+		if o.SourceLang != nil {
+			sourceLang = *o.SourceLang
+		}
+		pending = t.memory.fill(segments, pending, results, sourceLang, targetLang, t.metrics)
+	}
+
+	var billed int
+	for _, i := range pending {
+		billed += utf8.RuneCountInString(segments[i])
+	}
+
+	switch t.version {
+	case VersionV1:
+		return results, billed, t.translateSegmentsV1(ctx, segments, pending, results, targetLang, opts...)
+	case VersionV2:
+		saved, err := t.translateSegmentsV2(ctx, segments, pending, results, targetLang, opts...)
+		return results, billed - saved, err
+	default:
+		return nil, 0, fmt.Errorf("invalid API version: %d", t.version)
+	}
+}
+
+// maxChunkSize is the maximum number of characters of segments packed into
+// a single v1 request, see TranslateOptions.chunked.
+const maxChunkSize = 5000
+
+// chunkTag is the XML tag wrapping each segment of a chunk. It is sent as a
+// splitting tag, so that segments are translated as separate sentences.
+const chunkTag = "x-seg"
+
+var chunkSegmentRegexp = regexp.MustCompile(`(?s)<` + chunkTag + `>(.*?)</` + chunkTag + `>`)
+
+func (t *Translator) translateSegmentsV1(ctx context.Context, segments []string, pending []int, results []string, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	// Segments are sent one per request, unless they can be packed into
+	// chunks.
+	batches := make([][]int, 0, len(pending))
+	if o.chunked {
+		batches = chunkSegments(segments, pending, maxChunkSize)
+	} else {
+		for _, i := range pending {
+			batches = append(batches, []int{i})
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	ctx, concurrency := t.fanOut(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, concurrency)
+	for n, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		batchCtx := withLogAttrs(ctx, slog.Int("batch", n))
+		wg.Add(1)
+		go func(batch []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var err error
+			if len(batch) == 1 {
+				var resp *TranslationResultV1
+				if resp, err = t.translateTextV1(batchCtx, segments[batch[0]], targetLang, opts...); err == nil {
+					results[batch[0]] = resp.Data
+				}
+			} else {
+				err = t.translateChunkV1(batchCtx, segments, batch, results, targetLang, o)
+			}
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(batch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// translateChunkV1 translates the segments of batch in a single request,
+// each wrapped in chunkTag. Should the engine not keep the tags, the
+// segments are sent one by one instead.
+func (t *Translator) translateChunkV1(ctx context.Context, segments []string, batch []int, results []string, targetLang string, o TranslateOptions) error {
+	var sb strings.Builder
+	for _, i := range batch {
+		sb.WriteString("<" + chunkTag + ">" + segments[i] + "</" + chunkTag + ">")
+	}
+
+	tag := chunkTag
+	chunkOpts := o
+	chunkOpts.SplittingTags = append(slices.Clip(o.SplittingTags), &tag)
+	resp, err := t.translateTextV1(ctx, sb.String(), targetLang, withTranslateOptions(chunkOpts))
+	if err != nil {
+		return err
+	}
+
+	matches := chunkSegmentRegexp.FindAllStringSubmatch(resp.Data, -1)
+	if len(matches) == len(batch) {
+		for j, i := range batch {
+			results[i] = matches[j][1]
+		}
+		return nil
+	}
+	for _, i := range batch {
+		resp, err := t.translateTextV1(ctx, segments[i], targetLang, withTranslateOptions(o))
+		if err != nil {
+			return err
+		}
+		results[i] = resp.Data
+	}
+	return nil
+}
+
+// chunkSegments packs consecutive pending segments into batches of at most
+// size characters. A longer segment makes up a batch by itself.
+func chunkSegments(segments []string, pending []int, size int) [][]int {
+	var (
+		batches [][]int
+		batch   []int
+		n       int
+	)
+	for _, i := range pending {
+		length := utf8.RuneCountInString(segments[i])
+		if len(batch) > 0 && n+length > size {
+			batches = append(batches, batch)
+			batch, n = nil, 0
+		}
+		batch = append(batch, i)
+		n += length
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// translateSegmentsV2 returns the number of characters saved by sending
+// duplicates within a batch only once.
+func (t *Translator) translateSegmentsV2(ctx context.Context, segments []string, pending []int, results []string, targetLang string, opts ...TranslateOption) (int, error) {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return 0, fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	var saved int
+	for start := 0; start < len(pending); start += maxBatchSize {
+		batch := pending[start:min(start+maxBatchSize, len(pending))]
+
+		batchOpts := opts
+		if o.autoContext > 0 {
+			if c := precedingContext(segments[:batch[0]], o.autoContext); c != "" {
+				bo := o
+				if bo.Context != nil {
+					c = *bo.Context + "\n\n" + c
+				}
+				bo.Context = &c
+				batchOpts = []TranslateOption{withTranslateOptions(bo)}
+			}
+		}
+
+		texts := make([]string, len(batch))
+		for j, i := range batch {
+			texts[j] = segments[i]
+		}
+		batchCtx := withLogAttrs(ctx, slog.Int("batch", start/maxBatchSize))
+		resp, err := t.translateTextV2(batchCtx, texts, targetLang, batchOpts...)
+		if err != nil {
+			return saved, err
+		}
+		if len(resp.Translations) != len(texts) {
+			return saved, fmt.Errorf("mismatched number of translations, expected %d but got %d",
+				len(texts), len(resp.Translations))
+		}
+		for j, i := range batch {
+			results[i] = resp.Translations[j].Text
+		}
+		saved += resp.CharactersSaved
+	}
+	return saved, nil
+}
+
+// precedingContext returns the last of the preceding segments that fit in
+// limit characters, one per line, or the end of the last one if it does not
+// fit by itself.
+func precedingContext(preceding []string, limit int) string {
+	var (
+		lines []string
+		n     int
+	)
+	for i := len(preceding) - 1; i >= 0; i-- {
+		segment := strings.TrimSpace(preceding[i])
+		if segment == "" {
+			continue
+		}
+		length := utf8.RuneCountInString(segment)
+		if n+length > limit {
+			if len(lines) == 0 {
+				runes := []rune(segment)
+				lines = append(lines, string(runes[len(runes)-limit:]))
+			}
+			break
+		}
+		lines = append(lines, segment)
+		n += length + 1
+	}
+	slices.Reverse(lines)
+	return strings.Join(lines, "\n")
+}
+
+// hasAutoContext reports whether the options enable WithAutoContext.
+func hasAutoContext(opts []TranslateOption) bool {
+	var o TranslateOptions
+	return o.Gather(opts...) == nil && o.autoContext > 0
+}
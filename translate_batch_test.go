@@ -0,0 +1,117 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateSegments(t *testing.T) {
+	segments := []string{"", " "}
+	for i := range 2*maxBatchSize + 1 {
+		segments = append(segments, strconv.Itoa(i))
+	}
+
+	for _, test := range []struct {
+		version  string
+		requests int64
+	}{
+		{"/v1", 2*maxBatchSize + 1},
+		{"/v2", 3},
+	} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+test.version))
+
+		results, err := translator.translateSegments(context.Background(), segments, "de")
+		if assert.NoError(t, err) && assert.Len(t, results, len(segments)) {
+			assert.Equal(t, "", results[0])
+			assert.Equal(t, " ", results[1])
+			for i, result := range results[2:] {
+				assert.Equal(t, "[DE]"+strconv.Itoa(i), result)
+			}
+		}
+		assert.Equal(t, test.requests, server.requests.Load())
+	}
+}
+
+func TestTranslateSegmentsAutoContext(t *testing.T) {
+	segments := make([]string, maxBatchSize+1)
+	for i := range segments {
+		segments[i] = "Sentence " + strconv.Itoa(i) + ". "
+	}
+	text := strings.Join(segments, "")
+
+	for _, test := range []struct {
+		opts    []TranslateOption
+		context string
+	}{
+		{[]TranslateOption{WithAutoContext(25)}, "Sentence 48.\nSentence 49."},
+		{[]TranslateOption{WithContext("A novel."), WithAutoContext(25)}, "A novel.\n\nSentence 48.\nSentence 49."},
+		{[]TranslateOption{WithAutoContext(5)}, "e 49."},
+	} {
+		server := newMockServer(t)
+		budget := NewBudget()
+		translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithBudget(budget))
+
+		_, err := translator.TranslateText(segments, "DE", test.opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), server.requests.Load())
+		assert.Equal(t, test.context, server.lastRequest()["context"])
+		assert.Equal(t, []any{segments[maxBatchSize]}, server.lastRequest()["text"])
+
+		// The context is not billed.
+		assert.Equal(t, int64(utf8.RuneCountInString(text)), budget.Used("", BudgetDaily))
+	}
+}
+
+func TestPrecedingContext(t *testing.T) {
+	for _, test := range []struct {
+		preceding []string
+		limit     int
+		context   string
+	}{
+		{[]string{"One. ", "Two. ", "Three. "}, 100, "One.\nTwo.\nThree."},
+		{[]string{"One. ", "Two. ", "Three. "}, 11, "Two.\nThree."},
+		{[]string{"One. ", "\n\n", "Three. "}, 11, "One.\nThree."},
+		// Without a preceding segment fitting, the end of the last one is sent.
+		{[]string{"A very long one. ", "  "}, 9, "long one."},
+		{nil, 9, ""},
+	} {
+		assert.Equal(t, test.context, precedingContext(test.preceding, test.limit), test.preceding)
+	}
+}
+
+func TestWithAutoContext(t *testing.T) {
+	for _, test := range []struct {
+		limit int
+		valid bool
+	}{
+		{1, true},
+		{1000, true},
+		{0, false},
+		{-1, false},
+	} {
+		var o TranslateOptions
+		err := o.Gather(WithAutoContext(test.limit))
+		if test.valid {
+			assert.NoError(t, err, test.limit)
+			assert.Equal(t, test.limit, o.autoContext)
+		} else {
+			assert.Error(t, err, test.limit)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	_, err := translator.TranslateText("Bank", "DE", WithContext("We sat by the river."))
+	assert.NoError(t, err)
+	assert.Equal(t, "We sat by the river.", server.lastRequest()["context"])
+	assert.Equal(t, []any{"Bank"}, server.lastRequest()["text"])
+}
@@ -0,0 +1,116 @@
+package deeplx_translator
+
+import (
+	"context"
+)
+
+// TranslationJob is a translation running in the background, started with
+// TranslateAsync.
+type TranslationJob struct {
+	results chan TranslationJobResult
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	texts []string
+	err   error
+}
+
+// TranslationJobResult is the translation of a single segment of a job.
+type TranslationJobResult struct {
+	Index int
+	Text  string
+}
+
+// TranslationJobProgress reports how far a job has got.
+type TranslationJobProgress struct {
+	Done  int // segments translated so far
+	Total int
+
+	// BilledCharacters counts the characters sent so far, which is what the
+	// API bills. Blank segments and translation memory hits are not sent.
+	BilledCharacters int
+}
+
+// TranslateAsync starts translating the segments in the background and
+// returns right away. Segments are sent in batches of up to maxBatchSize
+// with v2, or WithMaxConcurrency with v1, so results and progress come in
+// steps.
+//
+// onProgress, if not nil, is called from the job's goroutine after every
+// batch. The job stops at the first error, or when ctx is done or the job is
+// cancelled.
+func (t *Translator) TranslateAsync(ctx context.Context, segments []string, targetLang string, onProgress func(TranslationJobProgress), opts ...TranslateOption) *TranslationJob {
+	ctx, cancel := context.WithCancel(ctx)
+	job := &TranslationJob{
+		results: make(chan TranslationJobResult, len(segments)),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		texts:   make([]string, len(segments)),
+	}
+
+	batchSize := maxBatchSize
+	if t.version == VersionV1 {
+		batchSize = max(t.maxConcurrency, 1)
+	}
+
+	go func() {
+		defer func() {
+			cancel()
+			close(job.results)
+			close(job.done)
+		}()
+
+		progress := TranslationJobProgress{Total: len(segments)}
+		for start := 0; start < len(segments); start += batchSize {
+			if err := ctx.Err(); err != nil {
+				job.err = err
+				return
+			}
+
+			batch := segments[start:min(start+batchSize, len(segments))]
+			results, billed, err := t.translateSegmentsBilled(ctx, batch, targetLang, opts...)
+			if err != nil {
+				job.err = err
+				return
+			}
+
+			for i, text := range results {
+				job.texts[start+i] = text
+				job.results <- TranslationJobResult{Index: start + i, Text: text}
+			}
+			progress.Done += len(batch)
+			progress.BilledCharacters += billed
+			if onProgress != nil {
+				onProgress(progress)
+			}
+		}
+	}()
+
+	return job
+}
+
+// Results returns a channel delivering the translated segments in order. It
+// is closed when the job ends, whether it succeeded or not.
+func (j *TranslationJob) Results() <-chan TranslationJobResult {
+	return j.results
+}
+
+// Cancel stops the job. Batches in flight are abandoned.
+func (j *TranslationJob) Cancel() {
+	j.cancel()
+}
+
+// Done returns a channel that is closed when the job ends.
+func (j *TranslationJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait waits for the job to end and returns the translations of all
+// segments, or the first error.
+func (j *TranslationJob) Wait() ([]string, error) {
+	<-j.done
+	if j.err != nil {
+		return nil, j.err
+	}
+	return j.texts, nil
+}
@@ -0,0 +1,62 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateAsync(t *testing.T) {
+	segments := []string{""}
+	for i := range maxBatchSize + 9 {
+		segments = append(segments, strconv.Itoa(i))
+	}
+
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	var progress []TranslationJobProgress
+	job := translator.TranslateAsync(context.Background(), segments, "de", func(p TranslationJobProgress) {
+		progress = append(progress, p)
+	})
+
+	var index int
+	for result := range job.Results() {
+		assert.Equal(t, index, result.Index)
+		if index > 0 {
+			assert.Equal(t, "[DE]"+segments[index], result.Text)
+		}
+		index++
+	}
+	assert.Equal(t, len(segments), index)
+
+	texts, err := job.Wait()
+	if assert.NoError(t, err) && assert.Len(t, texts, len(segments)) {
+		assert.Equal(t, "[DE]0", texts[1])
+	}
+	assert.Equal(t, []TranslationJobProgress{
+		{Done: maxBatchSize, Total: len(segments), BilledCharacters: 10 + 2*(maxBatchSize-11)},
+		{Done: len(segments), Total: len(segments), BilledCharacters: 10 + 2*(maxBatchSize-1)},
+	}, progress)
+}
+
+func TestTranslateAsyncCancel(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"), WithMaxConcurrency(1))
+
+	var job *TranslationJob
+	started := make(chan struct{})
+	job = translator.TranslateAsync(context.Background(), []string{"a", "b", "c"}, "de", func(TranslationJobProgress) {
+		<-started
+		job.Cancel()
+	})
+	close(started)
+	<-job.Done()
+
+	_, err := job.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, job.Results(), 1)
+	assert.Equal(t, int64(1), server.requests.Load())
+}
@@ -0,0 +1,72 @@
+package deeplx_translator
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// TranslateToMany translates text into each of the target languages
+// concurrently, with at most WithMaxConcurrency requests in flight.
+//
+// Target languages are compared case-insensitively, so duplicates such as
+// "zh" and "ZH" are only translated once. Both returned maps are keyed by the
+// target languages as supplied; each target appears in exactly one of them.
+// Targets that have not been translated when ctx is done report ctx.Err().
+func (t *Translator) TranslateToMany(ctx context.Context, text any, targetLangs []string, opts ...TranslateOption) (map[string]string, map[string]error) {
+	// Group the supplied targets by their normalized form.
+	var (
+		keys   []string
+		groups = make(map[string][]string)
+	)
+	for _, lang := range targetLangs {
+		key := strings.ToUpper(strings.TrimSpace(lang))
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], lang)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]string)
+		errs    = make(map[string]error)
+	)
+	collect := func(key, text string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, lang := range groups[key] {
+			if err != nil {
+				errs[lang] = err
+			} else {
+				results[lang] = text
+			}
+		}
+	}
+
+	ctx, concurrency := t.fanOut(ctx)
+	sem := make(chan struct{}, concurrency)
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			collect(key, "", ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// Use the first supplied spelling of the target language.
+			text, err := t.TranslateTextContext(ctx, text, groups[key][0], opts...)
+			collect(key, text, err)
+		}(key)
+	}
+	wg.Wait()
+
+	return results, errs
+}
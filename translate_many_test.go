@@ -0,0 +1,40 @@
+package deeplx_translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateToMany(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"), WithMaxConcurrency(2))
+
+	results, errs := translator.TranslateToMany(context.Background(),
+		"hello", []string{"de", "FR", "fr", "ja", "zh"})
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]string{
+		"de": "[DE]hello",
+		"FR": "[FR]hello",
+		"fr": "[FR]hello",
+		"ja": "[JA]hello",
+		"zh": "[ZH]hello",
+	}, results)
+	assert.EqualValues(t, 4, server.requests.Load())
+}
+
+func TestTranslateToManyCanceled(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, errs := translator.TranslateToMany(ctx, "hello", []string{"de", "fr"})
+	assert.Empty(t, results)
+	if assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs["de"], context.Canceled)
+		assert.ErrorIs(t, errs["fr"], context.Canceled)
+	}
+}
@@ -0,0 +1,55 @@
+package deeplx_translator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type PivotTranslationResult struct {
+	PivotLang    string
+	Intermediate string
+	Text         string
+}
+
+// TranslatePivot translates text into targetLang by way of pivotLang, i.e.
+// source→pivot→target, which can give better results for language pairs that
+// translate poorly directly.
+//
+// All options apply to both legs, so tag handling, ignore tags and the like
+// carry through. The second leg always uses pivotLang as its source language
+// and never uses a glossary, since the glossary's language pair can only
+// match the first leg.
+func (t *Translator) TranslatePivot(ctx context.Context, text any, pivotLang, targetLang string, opts ...TranslateOption) (*PivotTranslationResult, error) {
+	var first TranslateOptions
+	if err := first.Gather(opts...); err != nil {
+		return nil, fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	intermediate, err := t.TranslateTextContext(ctx, text, pivotLang, withTranslateOptions(first))
+	if err != nil {
+		return nil, fmt.Errorf("error translating to pivot language %s: %w", pivotLang, err)
+	}
+
+	result := &PivotTranslationResult{
+		PivotLang:    pivotLang,
+		Intermediate: intermediate,
+		Text:         intermediate,
+	}
+	if strings.EqualFold(pivotLang, targetLang) {
+		return result, nil
+	}
+
+	// Source languages never carry a regional variant, e.g. EN-GB → EN.
+	sourceLang, _, _ := strings.Cut(pivotLang, "-")
+
+	second := first
+	second.SourceLang = &sourceLang
+	second.GlossaryID = nil
+
+	result.Text, err = t.TranslateTextContext(ctx, intermediate, targetLang, withTranslateOptions(second))
+	if err != nil {
+		return nil, fmt.Errorf("error translating from pivot language %s: %w", pivotLang, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package deeplx_translator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslatePivot(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"))
+
+	result, err := translator.TranslatePivot(context.Background(), "hello", "EN-US", "ja",
+		WithSourceLang("KO"), WithGlossaryID("glossary"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &PivotTranslationResult{
+			PivotLang:    "EN-US",
+			Intermediate: "[EN-US]hello",
+			Text:         "[JA][EN-US]hello",
+		}, result)
+	}
+	assert.EqualValues(t, 2, server.requests.Load())
+	assert.Equal(t, "EN", server.lastRequest()["source_lang"])
+	assert.NotContains(t, server.lastRequest(), "glossary_id")
+
+	result, err = translator.TranslatePivot(context.Background(), "hello", "en", "EN")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[EN]hello", result.Text)
+		assert.Equal(t, result.Intermediate, result.Text)
+	}
+	assert.EqualValues(t, 3, server.requests.Load())
+}
@@ -0,0 +1,137 @@
+package deeplx_translator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// streamChunkSize is the size in bytes from which TranslateStream ends a
+// chunk at the next paragraph break. Paragraphs growing past twice the size
+// are cut at the next line break.
+const streamChunkSize = 32 << 10
+
+// TranslateStream translates plain text read from r and writes the
+// translation to w, without holding the whole text in memory.
+//
+// The text is read in chunks of whole paragraphs, which are separated by
+// blank lines and translated like TranslateIncremental does. At most
+// WithMaxConcurrency chunks are translated at once, and each chunk is
+// written as soon as it and all chunks before it are done, so the output
+// keeps the order of the input. Reading stops at the first error.
+func (t *Translator) TranslateStream(ctx context.Context, r io.Reader, w io.Writer, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+
+	ctx, concurrency := t.fanOut(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type chunk struct {
+		text string
+		err  error
+	}
+	var (
+		sem = make(chan struct{}, concurrency)
+		// Chunks in input order, bounding how far reading gets ahead of
+		// writing.
+		queue    = make(chan chan chunk, concurrency)
+		wg       sync.WaitGroup
+		writeErr error
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for done := range queue {
+			c := <-done
+			if writeErr != nil {
+				continue
+			}
+			if writeErr = c.err; writeErr == nil {
+				if _, writeErr = io.WriteString(w, c.text); writeErr != nil {
+					writeErr = fmt.Errorf("error writing translation: %w", writeErr)
+				}
+			}
+			if writeErr != nil {
+				cancel()
+			}
+		}
+	}()
+
+	translate := func(text string) bool {
+		done := make(chan chunk, 1)
+		select {
+		case queue <- done:
+		case <-ctx.Done():
+			return false
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			done <- chunk{err: ctx.Err()}
+			return false
+		}
+
+		go func() {
+			defer func() { <-sem }()
+			doc := parseParagraphs(text)
+			results, err := t.translateSegments(ctx, doc.segments, targetLang, withTranslateOptions(o))
+			if err != nil {
+				done <- chunk{err: err}
+				return
+			}
+			done <- chunk{text: doc.render(results, nil)}
+		}()
+		return true
+	}
+
+	readErr := readStreamChunks(bufio.NewReader(r), translate)
+	close(queue)
+	wg.Wait()
+
+	switch {
+	case writeErr != nil:
+		return writeErr
+	case readErr != nil:
+		return readErr
+	default:
+		return ctx.Err()
+	}
+}
+
+// readStreamChunks reads r in chunks of about streamChunkSize bytes, ended
+// at paragraph breaks where possible, until fn returns false.
+func readStreamChunks(r *bufio.Reader, fn func(string) bool) error {
+	var sb strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error reading text: %w", err)
+		}
+		sb.WriteString(line)
+
+		paragraphBreak := strings.TrimSpace(line) == ""
+		lineBreak := strings.HasSuffix(line, "\n")
+		if sb.Len() >= streamChunkSize && paragraphBreak || sb.Len() >= 2*streamChunkSize && lineBreak {
+			if !fn(sb.String()) {
+				return nil
+			}
+			sb.Reset()
+		}
+
+		if err != nil { // io.EOF
+			break
+		}
+	}
+	if sb.Len() > 0 {
+		fn(sb.String())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package deeplx_translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// inFlightClient records the most requests it has had in flight at once.
+type inFlightClient struct {
+	HTTPClient
+	n, max atomic.Int64
+}
+
+func (c *inFlightClient) Do(req *http.Request) (*http.Response, error) {
+	n := c.n.Add(1)
+	defer c.n.Add(-1)
+	for m := c.max.Load(); n > m && !c.max.CompareAndSwap(m, n); m = c.max.Load() {
+	}
+	time.Sleep(time.Millisecond)
+	return c.HTTPClient.Do(req)
+}
+
+func TestTranslateStream(t *testing.T) {
+	var input, expected strings.Builder
+	for i := 0; input.Len() < 5*streamChunkSize; i++ {
+		paragraph := fmt.Sprintf("Paragraph %d\n%s", i, strings.Repeat("has many words ", 100))
+		fmt.Fprintf(&input, "%s\n\n", paragraph)
+		fmt.Fprintf(&expected, "[DE]%s\n\n", paragraph)
+	}
+	// A single paragraph without breaks is cut at line breaks.
+	for range 3 * streamChunkSize / 10 {
+		input.WriteString("Log line\n")
+	}
+
+	for _, version := range []string{"/v1", "/v2"} {
+		server := newMockServer(t)
+		translator := NewTranslator("", WithBaseURL(server.URL+version), WithMaxConcurrency(2))
+
+		var output strings.Builder
+		err := translator.TranslateStream(context.Background(), strings.NewReader(input.String()), &output, "de")
+		if assert.NoError(t, err, version) {
+			assert.True(t, strings.HasPrefix(output.String(), expected.String()), version)
+			assert.Equal(t, 2, strings.Count(output.String()[expected.Len():], "[DE]"), version)
+		}
+	}
+
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	err := translator.TranslateStream(context.Background(), strings.NewReader(input.String()), failingWriter{}, "de")
+	assert.ErrorContains(t, err, "disk full")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = translator.TranslateStream(ctx, strings.NewReader(input.String()), &strings.Builder{}, "de")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTranslateStreamConcurrency(t *testing.T) {
+	var input strings.Builder
+	for i := 0; input.Len() < 3*streamChunkSize; i++ {
+		fmt.Fprintf(&input, "Paragraph %d %s\n\n", i, strings.Repeat("has many words ", 100))
+	}
+
+	// Chunks are translated concurrently, their paragraphs one by one.
+	server := newMockServer(t)
+	client := &inFlightClient{HTTPClient: http.DefaultClient}
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v1"), WithMaxConcurrency(2), WithHTTPClient(client))
+	err := translator.TranslateStream(context.Background(), strings.NewReader(input.String()), io.Discard, "de")
+	assert.NoError(t, err)
+	assert.Greater(t, server.requests.Load(), int64(3))
+	assert.LessOrEqual(t, client.max.Load(), int64(2))
+}
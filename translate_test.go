@@ -1,8 +1,10 @@
 package deeplx_translator
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -101,3 +103,78 @@ func TestTranslateText(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateTextV2Deduplication(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	result, err := translator.TranslateTextV2([]string{"OK", "Cancel", "OK", "Cancel", "Help", "OK"}, "DE")
+	if assert.NoError(t, err) && assert.Len(t, result.Translations, 6) {
+		for i, text := range []string{"OK", "Cancel", "OK", "Cancel", "Help", "OK"} {
+			assert.Equal(t, "[DE]"+text, result.Translations[i].Text)
+		}
+		assert.Equal(t, 2+6+2, result.CharactersSaved)
+	}
+	assert.Equal(t, []any{"OK", "Cancel", "Help"}, server.lastRequest()["text"])
+
+	_, billed, err := translator.translateSegmentsBilled(context.Background(), []string{"Yes", "No", "Yes"}, "DE")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, billed)
+}
+
+func TestTranslateTextV2NewerOptions(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	result, err := translator.TranslateTextV2([]string{"Hello", "world", "Hello"}, "DE",
+		WithModelType("prefer_quality_optimized"),
+		WithShowBilledCharacters(true),
+		WithTagHandling("html"),
+		WithTagHandlingVersion("v2"),
+		WithCustomInstructions([]string{"Use a friendly tone."}))
+	if assert.NoError(t, err) && assert.Len(t, result.Translations, 3) {
+		assert.Equal(t, "quality_optimized", result.Translations[0].ModelTypeUsed)
+		assert.Equal(t, 5, result.Translations[0].BilledCharacters)
+		assert.Equal(t, 5, result.Translations[1].BilledCharacters)
+		assert.Equal(t, 0, result.Translations[2].BilledCharacters)
+	}
+
+	req := server.lastRequest()
+	assert.Equal(t, "prefer_quality_optimized", req["model_type"])
+	assert.Equal(t, true, req["show_billed_characters"])
+	assert.Equal(t, "v2", req["tag_handling_version"])
+	assert.Equal(t, []any{"Use a friendly tone."}, req["custom_instructions"])
+
+	tooMany := make([]string, maxCustomInstructions+1)
+	for i := range tooMany {
+		tooMany[i] = "Be concise."
+	}
+	for _, option := range []TranslateOption{
+		WithModelType("fast"),
+		WithTagHandlingVersion("v3"),
+		WithCustomInstructions([]string{""}),
+		WithCustomInstructions([]string{strings.Repeat("a", maxCustomInstructionLength+1)}),
+		WithCustomInstructions(tooMany),
+	} {
+		var o TranslateOptions
+		assert.Error(t, o.Gather(option))
+	}
+
+	// Custom instructions require the quality-optimized model, whichever
+	// option comes first.
+	instructions := WithCustomInstructions([]string{"Use a friendly tone."})
+	for _, opts := range [][]TranslateOption{
+		{WithModelType("latency_optimized"), instructions},
+		{instructions, WithModelType("latency_optimized")},
+	} {
+		var o TranslateOptions
+		assert.Error(t, o.Gather(opts...))
+	}
+	for _, opts := range [][]TranslateOption{
+		{WithModelType("quality_optimized"), instructions},
+		{instructions, WithModelType("prefer_quality_optimized")},
+	} {
+		var o TranslateOptions
+		assert.NoError(t, o.Gather(opts...))
+	}
+}
@@ -1,8 +1,11 @@
 package deeplx_translator
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -14,11 +17,24 @@ const (
 	deeplFreeAPIURLv2 = "https://api-free.deepl.com/v2"
 )
 
+const defaultMaxConcurrency = 4
+
 type Translator struct {
 	client  HTTPClient
 	baseURL string
 	authKey string
 	version Version
+
+	maxConcurrency int
+	memory         *TranslationMemory
+	keys           *KeyPool
+	budget         *Budget
+	middleware     []Middleware
+	logger         *slog.Logger
+	metrics        MetricsCollector
+	tracer         Tracer
+
+	requests requestGroup
 }
 
 // TranslatorOption is a functional option for configuring the Translator.
@@ -45,6 +61,28 @@ func WithHTTPClient(c HTTPClient) TranslatorOption {
 	}
 }
 
+// WithMaxConcurrency limits the number of API requests a single call may have
+// in flight at once when it fans out work, e.g. in TranslateToMany.
+func WithMaxConcurrency(n int) TranslatorOption {
+	return func(t *Translator) {
+		t.maxConcurrency = n
+	}
+}
+
+// fannedOutKey marks the context of work that a call has fanned out already,
+// within the limit of WithMaxConcurrency.
+type fannedOutKey struct{}
+
+// fanOut returns a context for the work a call fans out, and the number of
+// requests it may have in flight at once. Work that has been fanned out
+// already is not fanned out again, so that the limit holds across levels.
+func (t *Translator) fanOut(ctx context.Context) (context.Context, int) {
+	if ctx.Value(fannedOutKey{}) != nil {
+		return ctx, 1
+	}
+	return context.WithValue(ctx, fannedOutKey{}, true), max(t.maxConcurrency, 1)
+}
+
 // NewTranslator creates a new translator.
 func NewTranslator(authKey string, opts ...TranslatorOption) *Translator {
 	// Determine default base url based on the auth key.
@@ -62,6 +100,8 @@ func NewTranslator(authKey string, opts ...TranslatorOption) *Translator {
 		},
 		baseURL: baseURL,
 		authKey: authKey,
+
+		maxConcurrency: defaultMaxConcurrency,
 	}
 	t.applyOptions(opts...)
 
@@ -80,20 +120,96 @@ func (t *Translator) applyOptions(opts ...TranslatorOption) {
 	}
 }
 
-// callAPI calls the supplied API endpoint with the provided parameters and returns the response.
-func (t *Translator) callAPI(method string, endpoint string, headers http.Header, body io.Reader) (*http.Response, error) {
-	apiURL, err := url.JoinPath(t.baseURL, endpoint)
+// callAPI calls the supplied endpoint of the given API version with the provided parameters and returns the response.
+func (t *Translator) callAPI(ctx context.Context, version Version, method string, endpoint string, headers http.Header, body io.Reader) (*http.Response, error) {
+	if t.keys == nil {
+		return t.send(withLogAttrs(ctx, slog.Int("attempt", 1)), t.versionBaseURL(t.baseURL, version), t.authKey, method, endpoint, headers, body)
+	}
+
+	// The body is sent again with every key tried.
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+	// Every key is tried at most once per call, even if the quota of a key
+	// is already reset by the time the pool gets back to it.
+	var lastErr error
+	for attempt := 1; attempt <= len(t.keys.keys); attempt++ {
+		key, check, err := t.keys.acquire()
+		if err != nil {
+			return nil, err
+		}
+		if check {
+			exceeded, err := t.quotaExceeded(ctx, key.key)
+			if err != nil {
+				return nil, err
+			}
+			if exceeded {
+				t.exhaustKey(ctx, key)
+				lastErr = httpError(456)
+				continue
+			}
+		}
+
+		attemptCtx := withLogAttrs(ctx, slog.Int("attempt", attempt))
+		res, err := t.send(attemptCtx, t.versionBaseURL(t.keyBaseURL(key.key), version), key.key, method, endpoint, headers, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != 456 {
+			return res, nil
+		}
+		//nolint:errcheck
+		res.Body.Close()
+		t.exhaustKey(ctx, key)
+		lastErr = httpError(456)
+	}
+	if lastErr == nil {
+		return nil, ErrQuotaExceeded
+	}
+	return nil, fmt.Errorf("%w: %w", ErrQuotaExceeded, lastErr)
+}
+
+// versionBaseURL returns the base URL of the given API version. A DeepLX
+// server, i.e. v1, serves the official v2 API next to its own v1 one.
+func (t *Translator) versionBaseURL(baseURL string, version Version) string {
+	if t.version == VersionV1 && version == VersionV2 {
+		return strings.TrimSuffix(baseURL, "/v1") + "/v2"
+	}
+	return baseURL
+}
+
+// exhaustKey leaves a key that has exceeded its quota out of the key pool.
+func (t *Translator) exhaustKey(ctx context.Context, key *poolKey) {
+	t.keys.exhaust(key)
+	if t.metrics != nil {
+		if u, err := url.Parse(t.keyBaseURL(key.key)); err == nil {
+			t.metrics.ObserveKeyExhausted(u.Host)
+		}
+	}
+	if t.logger != nil {
+		t.logger.LogAttrs(ctx, slog.LevelWarn, "deeplx auth key quota exceeded",
+			slog.Int("available", t.keys.Available()))
+	}
+}
+
+// send sends a single request to the API.
+func (t *Translator) send(ctx context.Context, baseURL, authKey string, method string, endpoint string, headers http.Header, body io.Reader) (*http.Response, error) {
+	apiURL, err := url.JoinPath(baseURL, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("error joining API url: %w", err)
 	}
 
-	req, err := http.NewRequest(method, apiURL, body)
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
-	if t.authKey != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("DeepL-Auth-Key %s", t.authKey))
+	if authKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("DeepL-Auth-Key %s", authKey))
 	}
 	for k, vs := range headers {
 		for _, v := range vs {
@@ -101,7 +217,14 @@ func (t *Translator) callAPI(method string, endpoint string, headers http.Header
 		}
 	}
 
-	return t.client.Do(req)
+	spanCtx, span := t.startHTTPSpan(ctx, req)
+	start := time.Now()
+	res, err := t.client.Do(req.WithContext(spanCtx))
+	latency := time.Since(start)
+	endHTTPSpan(span, res, err)
+	t.logAPICall(ctx, req, res, err, latency)
+	t.observeRequest(ctx, req, res, latency)
+	return res, err
 }
 
 // isFreeAccountAuthKey determines whether the supplied auth key belongs to a Free account.
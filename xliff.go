@@ -0,0 +1,299 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// xliffIgnoreTags are the inline elements holding native code, which must
+// not be translated.
+var xliffIgnoreTags = []string{"ph", "bpt", "ept", "it"}
+
+var xmlTagNameRegexp = regexp.MustCompile(`^<([^\s/>]+)`)
+
+// XLIFFFile is a parsed XLIFF 1.2 or 2.x document. Apart from the targets
+// that have changed, the document is written back exactly as it was read.
+type XLIFFFile struct {
+	Version    string
+	SourceLang string
+	Units      []*XLIFFUnit
+
+	data []byte
+}
+
+// XLIFFUnit is a translatable unit, i.e. a <trans-unit> in XLIFF 1.2 or a
+// <segment> in XLIFF 2.x. Source and Target hold the raw XML content of the
+// respective elements, including any inline elements.
+type XLIFFUnit struct {
+	ID     string
+	Source string
+	Target *string
+
+	original    *string // Target as read
+	machine     bool    // whether Target is a machine translation
+	noTranslate bool    // whether the unit is marked with translate="no"
+
+	sourceEnd   int64 // offset after </source>
+	sourceStart int64 // offset of <source>
+	sourceTag   string
+	targetStart int64 // offset of <target>, or -1 if absent
+	targetEnd   int64
+	targetTag   string   // raw start tag of the target
+	segmentTag  [2]int64 // offsets of the <segment> start tag, XLIFF 2.x only
+}
+
+func (u *XLIFFUnit) isSegment() bool {
+	return u.segmentTag[1] > 0
+}
+
+// ParseXLIFF reads an XLIFF 1.2 or 2.x document.
+func ParseXLIFF(r io.Reader) (*XLIFFFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading XLIFF: %w", err)
+	}
+
+	var (
+		f     = &XLIFFFile{data: data}
+		d     = xml.NewDecoder(bytes.NewReader(data))
+		stack []string
+		skip  []bool // whether the elements of stack are marked with translate="no"
+		unit  *XLIFFUnit
+		inner int64 // offset where the content of source or target begins
+		id    string
+	)
+	for {
+		start := d.InputOffset()
+		token, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing XLIFF: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, token.Name.Local)
+
+			// The translate attribute is inherited by nested elements.
+			noTranslate := len(skip) > 0 && skip[len(skip)-1]
+			switch xmlAttr(token, "translate") {
+			case "no":
+				noTranslate = true
+			case "yes":
+				noTranslate = false
+			}
+			skip = append(skip, noTranslate)
+
+			switch token.Name.Local {
+			case "xliff":
+				f.Version = xmlAttr(token, "version")
+				f.SourceLang = xmlAttr(token, "srcLang")
+			case "file":
+				if lang := xmlAttr(token, "source-language"); lang != "" {
+					f.SourceLang = lang
+				}
+			case "trans-unit":
+				unit = &XLIFFUnit{ID: xmlAttr(token, "id"), targetStart: -1, noTranslate: noTranslate}
+				f.Units = append(f.Units, unit)
+			case "unit":
+				id = xmlAttr(token, "id")
+			case "segment":
+				unit = &XLIFFUnit{ID: id, targetStart: -1, noTranslate: noTranslate}
+				if segmentID := xmlAttr(token, "id"); segmentID != "" {
+					unit.ID += "/" + segmentID
+				}
+				unit.segmentTag = [2]int64{start, d.InputOffset()}
+				f.Units = append(f.Units, unit)
+			case "source":
+				if unit != nil && (parent == "trans-unit" || parent == "segment") {
+					unit.sourceStart = start
+					unit.sourceTag = string(data[start:d.InputOffset()])
+					inner = d.InputOffset()
+				}
+			case "target":
+				if unit != nil && (parent == "trans-unit" || parent == "segment") {
+					unit.targetStart = start
+					unit.targetTag = string(data[start:d.InputOffset()])
+					inner = d.InputOffset()
+				}
+			}
+		case xml.EndElement:
+			stack, skip = stack[:len(stack)-1], skip[:len(skip)-1]
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			if unit == nil || (parent != "trans-unit" && parent != "segment") {
+				continue
+			}
+
+			switch token.Name.Local {
+			case "source":
+				unit.Source = string(data[inner:start])
+				unit.sourceEnd = d.InputOffset()
+			case "target":
+				target := string(data[inner:start])
+				unit.Target, unit.original = &target, &target
+				unit.targetEnd = d.InputOffset()
+			}
+		}
+	}
+	return f, nil
+}
+
+// WriteTo writes the document with the changed targets spliced in.
+func (f *XLIFFFile) WriteTo(w io.Writer) (int64, error) {
+	type replacement struct {
+		start, end int64
+		text       string
+	}
+	var replacements []replacement
+
+	for _, unit := range f.Units {
+		if unit.Target == nil || (unit.original != nil && *unit.Target == *unit.original) {
+			continue
+		}
+
+		tag := unit.targetTag
+		if tag == "" {
+			// Without a source, there is nowhere to add the target.
+			m := xmlTagNameRegexp.FindStringSubmatch(unit.sourceTag)
+			if m == nil {
+				continue
+			}
+			tag = "<" + strings.Replace(m[1], "source", "target", 1) + ">"
+		}
+		if unit.machine && !unit.isSegment() {
+			tag = setXMLAttr(tag, "state", "needs-review-translation")
+			tag = setXMLAttr(tag, "state-qualifier", "mt-suggestion")
+		}
+		tag = strings.TrimSuffix(strings.TrimSuffix(tag, ">"), "/") + ">"
+		element := tag + *unit.Target + "</" + xmlTagNameRegexp.FindStringSubmatch(tag)[1] + ">"
+
+		if unit.targetStart >= 0 {
+			replacements = append(replacements, replacement{unit.targetStart, unit.targetEnd, element})
+		} else {
+			replacements = append(replacements, replacement{unit.sourceEnd, unit.sourceEnd,
+				indentationBefore(f.data, unit.sourceStart) + element})
+		}
+
+		if unit.machine && unit.isSegment() {
+			segment := string(f.data[unit.segmentTag[0]:unit.segmentTag[1]])
+			segment = setXMLAttr(segment, "state", "translated")
+			segment = setXMLAttr(segment, "subState", "deeplx:machine-translation")
+			replacements = append(replacements, replacement{unit.segmentTag[0], unit.segmentTag[1], segment})
+		}
+	}
+	slices.SortFunc(replacements, func(a, b replacement) int {
+		return int(a.start - b.start)
+	})
+
+	var (
+		buf  bytes.Buffer
+		last int64
+	)
+	for _, r := range replacements {
+		buf.Write(f.data[last:r.start])
+		buf.WriteString(r.text)
+		last = r.end
+	}
+	buf.Write(f.data[last:])
+	return buf.WriteTo(w)
+}
+
+// TranslateXLIFF translates the source of every unit without a target, or
+// with an empty one, and marks the new targets as machine translated. Units
+// marked with translate="no", directly or through an enclosing element, and
+// units without a source are left alone.
+//
+// Inline elements such as <g>, <x/>, <ph>, <pc> and <sc/> are sent as XML
+// tags, with the content of native code elements ignored, so they survive
+// translation. The document's source language is used unless one is set
+// with WithSourceLang.
+func (t *Translator) TranslateXLIFF(ctx context.Context, file *XLIFFFile, targetLang string, opts ...TranslateOption) error {
+	var o TranslateOptions
+	if err := o.Gather(opts...); err != nil {
+		return fmt.Errorf("error setting translate option: %w", err)
+	}
+	if o.SourceLang == nil && file.SourceLang != "" {
+		sourceLang, _, _ := strings.Cut(strings.ToUpper(file.SourceLang), "-")
+		o.SourceLang = &sourceLang
+	}
+	tagHandling := "xml"
+	o.TagHandling = &tagHandling
+	for _, tag := range xliffIgnoreTags {
+		o.IgnoreTags = append(o.IgnoreTags, &tag)
+	}
+
+	var (
+		units    []*XLIFFUnit
+		segments []string
+	)
+	for _, unit := range file.Units {
+		if unit.noTranslate || unit.sourceTag == "" {
+			continue
+		}
+		if unit.Target != nil && strings.TrimSpace(*unit.Target) != "" {
+			continue
+		}
+		units = append(units, unit)
+		segments = append(segments, unit.Source)
+	}
+
+	results, err := t.translateSegments(ctx, segments, targetLang, withTranslateOptions(o))
+	if err != nil {
+		return err
+	}
+	for i, unit := range units {
+		unit.Target = &results[i]
+		unit.machine = true
+	}
+	return nil
+}
+
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// setXMLAttr sets an attribute of a raw XML start tag.
+func setXMLAttr(tag string, name, value string) string {
+	value = xmlEscaper.Replace(value)
+	re := regexp.MustCompile(`(\s` + regexp.QuoteMeta(name) + `\s*=\s*)("[^"]*"|'[^']*')`)
+	if re.MatchString(tag) {
+		return re.ReplaceAllLiteralString(tag, re.FindStringSubmatch(tag)[1]+`"`+value+`"`)
+	}
+
+	end := len(tag) - 1
+	if strings.HasSuffix(tag, "/>") {
+		end--
+	}
+	return tag[:end] + " " + name + `="` + value + `"` + tag[end:]
+}
+
+// indentationBefore returns a line break followed by the indentation of the
+// line at offset, for inserting a sibling element.
+func indentationBefore(data []byte, offset int64) string {
+	line := data[bytes.LastIndexByte(data[:offset], '\n')+1 : offset]
+	if len(bytes.TrimSpace(line)) > 0 {
+		return ""
+	}
+	return "\n" + string(line)
+}
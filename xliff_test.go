@@ -0,0 +1,159 @@
+package deeplx_translator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xliff12Sample = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file source-language="en-US" target-language="de" datatype="plaintext" original="app">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hello <g id="1">world</g>!</source>
+      </trans-unit>
+      <trans-unit id="count">
+        <source>You have <ph id="1">%d</ph> messages<x id="2"/></source>
+        <target/>
+      </trans-unit>
+      <trans-unit id="done">
+        <source>Done</source>
+        <target state="final">Fertig</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+const xliff20Sample = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="fr">
+  <file id="f1">
+    <unit id="u1">
+      <segment id="s1">
+        <source>Click <pc id="1">here</pc> &amp; wait.</source>
+      </segment>
+      <segment id="s2" state="initial">
+        <source>Bye.</source>
+        <target></target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`
+
+func TestParseXLIFF(t *testing.T) {
+	file, err := ParseXLIFF(strings.NewReader(xliff12Sample))
+	if assert.NoError(t, err) && assert.Len(t, file.Units, 3) {
+		assert.Equal(t, "1.2", file.Version)
+		assert.Equal(t, "en-US", file.SourceLang)
+		assert.Equal(t, "greeting", file.Units[0].ID)
+		assert.Equal(t, `Hello <g id="1">world</g>!`, file.Units[0].Source)
+		assert.Nil(t, file.Units[0].Target)
+		if assert.NotNil(t, file.Units[1].Target) {
+			assert.Equal(t, "", *file.Units[1].Target)
+		}
+		if assert.NotNil(t, file.Units[2].Target) {
+			assert.Equal(t, "Fertig", *file.Units[2].Target)
+		}
+
+		buf := &bytes.Buffer{}
+		_, err = file.WriteTo(buf)
+		if assert.NoError(t, err) {
+			assert.Equal(t, xliff12Sample, buf.String())
+		}
+	}
+
+	file, err = ParseXLIFF(strings.NewReader(xliff20Sample))
+	if assert.NoError(t, err) && assert.Len(t, file.Units, 2) {
+		assert.Equal(t, "2.0", file.Version)
+		assert.Equal(t, "en", file.SourceLang)
+		assert.Equal(t, "u1/s1", file.Units[0].ID)
+		assert.Equal(t, `Click <pc id="1">here</pc> &amp; wait.`, file.Units[0].Source)
+	}
+}
+
+func TestTranslateXLIFF(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	file, err := ParseXLIFF(strings.NewReader(xliff12Sample))
+	if assert.NoError(t, err) && assert.NoError(t, translator.TranslateXLIFF(context.Background(), file, "de")) {
+		buf := &bytes.Buffer{}
+		_, _ = file.WriteTo(buf)
+		assert.Equal(t, strings.NewReplacer(
+			`<source>Hello <g id="1">world</g>!</source>`,
+			`<source>Hello <g id="1">world</g>!</source>`+"\n        "+
+				`<target state="needs-review-translation" state-qualifier="mt-suggestion">[DE]Hello <g id="1">world</g>!</target>`,
+			`<target/>`,
+			`<target state="needs-review-translation" state-qualifier="mt-suggestion">[DE]You have <ph id="1">%d</ph> messages<x id="2"/></target>`,
+		).Replace(xliff12Sample), buf.String())
+		assert.Equal(t, "EN", server.lastRequest()["source_lang"])
+		assert.Equal(t, "xml", server.lastRequest()["tag_handling"])
+	}
+
+	file, err = ParseXLIFF(strings.NewReader(xliff20Sample))
+	if assert.NoError(t, err) && assert.NoError(t, translator.TranslateXLIFF(context.Background(), file, "fr")) {
+		buf := &bytes.Buffer{}
+		_, _ = file.WriteTo(buf)
+		assert.Equal(t, strings.NewReplacer(
+			`<segment id="s1">`,
+			`<segment id="s1" state="translated" subState="deeplx:machine-translation">`,
+			`<source>Click <pc id="1">here</pc> &amp; wait.</source>`,
+			`<source>Click <pc id="1">here</pc> &amp; wait.</source>`+"\n        "+
+				`<target>[FR]Click <pc id="1">here</pc> &amp; wait.</target>`,
+			`<segment id="s2" state="initial">`,
+			`<segment id="s2" state="translated" subState="deeplx:machine-translation">`,
+			`<target></target>`,
+			`<target>[FR]Bye.</target>`,
+		).Replace(xliff20Sample), buf.String())
+	}
+}
+
+func TestTranslateXLIFFSkipped(t *testing.T) {
+	server := newMockServer(t)
+	translator := NewTranslator("", WithBaseURL(server.URL+"/v2"))
+
+	source := `<xliff version="1.2">
+  <file source-language="en">
+    <body>
+      <trans-unit id="brand" translate="no">
+        <source>Brand</source>
+      </trans-unit>
+      <group translate="no">
+        <trans-unit id="legal">
+          <source>Legal</source>
+        </trans-unit>
+        <trans-unit id="tagline" translate="yes">
+          <source>Tagline</source>
+        </trans-unit>
+      </group>
+      <trans-unit id="empty">
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+	file, err := ParseXLIFF(strings.NewReader(source))
+	if !assert.NoError(t, err) || !assert.NoError(t, translator.TranslateXLIFF(context.Background(), file, "de")) {
+		return
+	}
+	assert.Nil(t, file.Units[0].Target)
+	assert.Nil(t, file.Units[1].Target)
+	if assert.NotNil(t, file.Units[2].Target) {
+		assert.Equal(t, "[DE]Tagline", *file.Units[2].Target)
+	}
+	assert.Nil(t, file.Units[3].Target)
+	assert.Equal(t, []any{"Tagline"}, server.lastRequest()["text"])
+
+	// A target set on a unit without a source is not written out.
+	target := "Leer"
+	file.Units[3].Target = &target
+	buf := &bytes.Buffer{}
+	_, err = file.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "Leer")
+}